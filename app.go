@@ -0,0 +1,94 @@
+package controller
+
+import (
+	"net/http"
+	"reflect"
+	"sync"
+)
+
+// App holds application-level singletons such as configuration, templates
+// and clients, for applications that would rather not keep them in package
+// level variables. Actions bound to an App can reach it with Base.App, and
+// get their `inject:""` fields populated from the values provided to it:
+//
+//	app := &controller.App{}
+//	app.Provide(db)
+//	app.Set("config", cfg)
+//
+//	mux.Handle("GET /users", app.Action((*UserController).Index))
+//
+// The zero value is an empty App ready to use. An App is safe for concurrent
+// use.
+type App struct {
+	providers registry
+
+	mu     sync.RWMutex
+	values map[string]interface{}
+}
+
+// Action is like the package level Action, with the action bound to a.
+func (a *App) Action(action interface{}, opts ...Option) http.Handler {
+	return Action(action, append([]Option{InApp(a)}, opts...)...)
+}
+
+// InApp binds an action to app.
+func InApp(app *App) Option {
+	return func(o *options) {
+		o.app = app
+	}
+}
+
+// Provide registers value for injection into the controllers of actions
+// bound to a, following the rules of the package level Provide. Controllers
+// of actions bound to an App are populated from its values only.
+func (a *App) Provide(value interface{}) {
+	a.providers.provide(value)
+}
+
+// ProvideFunc registers constructor to construct values for injection into
+// the controllers of actions bound to a, following the rules of the package
+// level ProvideFunc.
+func (a *App) ProvideFunc(constructor interface{}, scope Scope) {
+	a.providers.provideFunc(constructor, scope)
+}
+
+// Resolve returns the value provided to a for a field of type t, which makes
+// an App an Injector. Request scoped values can only be injected by actions
+// bound to a, not resolved.
+func (a *App) Resolve(t reflect.Type) (interface{}, error) {
+	v, _, err := a.providers.get(t, nil)
+	if err != nil {
+		return nil, err
+	}
+	return v.Interface(), nil
+}
+
+// Set stores value under key.
+func (a *App) Set(key string, value interface{}) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.values == nil {
+		a.values = make(map[string]interface{})
+	}
+	a.values[key] = value
+}
+
+// Get returns the value stored under key with Set, and whether there is one.
+func (a *App) Get(key string) (interface{}, bool) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	value, ok := a.values[key]
+	return value, ok
+}
+
+// App returns the App the action serving the request is bound to, or nil if
+// it is not bound to one.
+func (b *Base) App() *App {
+	if b.Request == nil {
+		return nil
+	}
+	if o, ok := b.Request.Context().Value(optionsKey{}).(*options); ok {
+		return o.app
+	}
+	return nil
+}
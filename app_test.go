@@ -0,0 +1,39 @@
+package controller
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type AppController struct {
+	Base
+	Config *injectConfig `inject:""`
+}
+
+func (c *AppController) Index() error {
+	name, _ := c.App().Get("name")
+	return c.Textf(http.StatusOK, "%s in %s", name, c.Config.Env)
+}
+
+func TestApp(t *testing.T) {
+	app := &App{}
+	app.Provide(&injectConfig{Env: "production"})
+	app.Set("name", "shop")
+
+	for _, h := range []http.Handler{
+		app.Action((*AppController).Index),
+		ActionOf((*AppController).Index, InApp(app)),
+	} {
+		rw := httptest.NewRecorder()
+		h.ServeHTTP(rw, httptest.NewRequest("GET", "/", nil))
+		equals(t, http.StatusOK, rw.Code)
+		equals(t, "shop in production", rw.Body.String())
+	}
+
+	// Actions not bound to the App do not see its values.
+	rw := httptest.NewRecorder()
+	Action((*AppController).Index).ServeHTTP(rw, httptest.NewRequest("GET", "/", nil))
+	equals(t, http.StatusInternalServerError, rw.Code)
+	assert(t, (&Base{}).App() == nil, "expected no App\n")
+}
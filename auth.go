@@ -0,0 +1,158 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"sync"
+)
+
+// Authenticator is implemented by controllers that authenticate requests
+// before their actions run. Authenticate is called after Init and before
+// the filters and the action:
+//
+//	func (c *AccountController) Authenticate() error {
+//		user, err := c.Sessions.User(c.Request)
+//		if err != nil {
+//			return err
+//		}
+//		c.User = user
+//		return nil
+//	}
+//
+// If Authenticate returns an error, the action is not invoked and the error
+// is reported through the Error method of the controller with 401
+// Unauthorized, unless it is an HTTPError carrying another code.
+type Authenticator interface {
+	Authenticate() error
+}
+
+// authenticate authenticates the request of c if c is an Authenticator.
+func authenticate(c Controller) error {
+	a, ok := c.(Authenticator)
+	if !ok {
+		return nil
+	}
+	err := a.Authenticate()
+	if err == nil {
+		return nil
+	}
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		return err
+	}
+	return &HTTPError{Code: http.StatusUnauthorized, Err: err}
+}
+
+// Authorizer is implemented by controllers that decide which actions the
+// authenticated user may invoke. Authorize is called after Authenticate and
+// before the filters and the action, with the name of the action method,
+// such as "Delete":
+//
+//	func (c *PostController) Authorize(action string) error {
+//		if action == "Delete" && !c.User.Owns(c.Param("id")) {
+//			return errors.New("Only the author may delete a post")
+//		}
+//		return nil
+//	}
+//
+// If Authorize returns an error, the action is not invoked and the error is
+// reported through the Error method of the controller with 403 Forbidden,
+// unless it is an HTTPError carrying another code.
+type Authorizer interface {
+	Authorize(action string) error
+}
+
+// RoleHolder is implemented by controllers that know the roles of the user
+// making the request, usually found by Authenticate. It is needed for the
+// actions restricted with RequireRoles.
+type RoleHolder interface {
+	Roles() []string
+}
+
+// requiredRoles holds the roles registered with RequireRoles, by controller
+// type and action name.
+var requiredRoles struct {
+	sync.RWMutex
+	roles map[reflect.Type]map[string][]string
+}
+
+// RequireRoles restricts action, a method expression as accepted by Action,
+// to users having at least one of roles, so that controllers declare who may
+// invoke what in one place instead of checking it in every action:
+//
+//	func init() {
+//		controller.RequireRoles((*UserController).Delete, "admin")
+//		controller.RequireRoles((*UserController).Update, "admin", "support")
+//	}
+//
+// The controller must implement RoleHolder. Requests from users with none of
+// the roles are answered with 403 Forbidden through the Error method of the
+// controller. Calling RequireRoles again for an action replaces its roles.
+func RequireRoles(action interface{}, roles ...string) {
+	val := reflect.ValueOf(action)
+	t, err := controllerType(val)
+	if err != nil {
+		panic(err)
+	}
+	requiredRoles.Lock()
+	defer requiredRoles.Unlock()
+	if requiredRoles.roles == nil {
+		requiredRoles.roles = make(map[reflect.Type]map[string][]string)
+	}
+	t = reflect.PtrTo(t)
+	if requiredRoles.roles[t] == nil {
+		requiredRoles.roles[t] = make(map[string][]string)
+	}
+	requiredRoles.roles[t][actionName(val)] = roles
+}
+
+// ActionName names the action of a handler created with Handler, for
+// Authorize and RequireRoles. Action, ActionOf and ActionWith name their
+// actions after the method of the method expression.
+func ActionName(name string) Option {
+	return func(o *options) {
+		o.name = name
+	}
+}
+
+// authorize checks that the request of c may invoke the action with the
+// given name.
+func authorize(c Controller, action string) error {
+	requiredRoles.RLock()
+	roles, restricted := requiredRoles.roles[reflect.TypeOf(c)][action]
+	requiredRoles.RUnlock()
+	if restricted && !hasRole(c, roles) {
+		return &HTTPError{Code: http.StatusForbidden}
+	}
+
+	a, ok := c.(Authorizer)
+	if !ok {
+		return nil
+	}
+	err := a.Authorize(action)
+	if err == nil {
+		return nil
+	}
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		return err
+	}
+	return &HTTPError{Code: http.StatusForbidden, Err: err}
+}
+
+// hasRole reports whether the user of the request of c has one of roles.
+func hasRole(c Controller, roles []string) bool {
+	h, ok := c.(RoleHolder)
+	if !ok {
+		return false
+	}
+	for _, have := range h.Roles() {
+		for _, role := range roles {
+			if have == role {
+				return true
+			}
+		}
+	}
+	return false
+}
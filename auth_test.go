@@ -0,0 +1,119 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+type AuthController struct {
+	Base
+	user string
+}
+
+func (c *AuthController) Authenticate() error {
+	switch c.Request.Header.Get("Authorization") {
+	case "":
+		return errors.New("Missing credentials")
+	case "Bearer banned":
+		return &HTTPError{Code: http.StatusForbidden, Err: errors.New("Banned")}
+	}
+	c.user = "gopher"
+	return nil
+}
+
+func (c *AuthController) Show() error {
+	return c.Text(http.StatusOK, c.user)
+}
+
+func TestAuthenticate(t *testing.T) {
+	var tests = []struct {
+		auth string
+		code int
+		body string
+	}{
+		{"", http.StatusUnauthorized, "Missing credentials\n"},
+		{"Bearer banned", http.StatusForbidden, "Banned\n"},
+		{"Bearer token", http.StatusOK, "gopher"},
+	}
+	for _, h := range []http.Handler{Action((*AuthController).Show), ActionOf((*AuthController).Show)} {
+		for _, test := range tests {
+			r := httptest.NewRequest("GET", "/", nil)
+			if test.auth != "" {
+				r.Header.Set("Authorization", test.auth)
+			}
+			rw := httptest.NewRecorder()
+			h.ServeHTTP(rw, r)
+			equals(t, test.code, rw.Code)
+			equals(t, test.body, rw.Body.String())
+		}
+	}
+}
+
+type RolesController struct {
+	Base
+	roles []string
+}
+
+func (c *RolesController) Authenticate() error {
+	if role := c.Request.Header.Get("X-Role"); role != "" {
+		c.roles = []string{"member", role}
+	}
+	return nil
+}
+
+func (c *RolesController) Roles() []string {
+	return c.roles
+}
+
+func (c *RolesController) Authorize(action string) error {
+	if action == "Archive" && c.Request.URL.Query().Get("locked") != "" {
+		return errors.New("Locked")
+	}
+	return nil
+}
+
+func (c *RolesController) Show() error {
+	return c.Text(http.StatusOK, "show")
+}
+
+func (c *RolesController) Archive() error {
+	return c.Text(http.StatusOK, "archived")
+}
+
+func TestAuthorize(t *testing.T) {
+	RequireRoles((*RolesController).Archive, "admin", "editor")
+	defer func() {
+		requiredRoles.Lock()
+		delete(requiredRoles.roles, reflect.TypeOf(&RolesController{}))
+		requiredRoles.Unlock()
+	}()
+
+	var tests = []struct {
+		action interface{}
+		role   string
+		query  string
+		code   int
+	}{
+		{(*RolesController).Show, "", "", http.StatusOK},
+		{(*RolesController).Archive, "", "", http.StatusForbidden},
+		{(*RolesController).Archive, "viewer", "", http.StatusForbidden},
+		{(*RolesController).Archive, "editor", "", http.StatusOK},
+		{(*RolesController).Archive, "admin", "?locked=1", http.StatusForbidden},
+	}
+	for _, test := range tests {
+		r := httptest.NewRequest("POST", "/"+test.query, nil)
+		if test.role != "" {
+			r.Header.Set("X-Role", test.role)
+		}
+		rw := httptest.NewRecorder()
+		Action(test.action).ServeHTTP(rw, r)
+		equals(t, test.code, rw.Code)
+	}
+
+	rw := httptest.NewRecorder()
+	ActionOf((*RolesController).Archive).ServeHTTP(rw, httptest.NewRequest("POST", "/", nil))
+	equals(t, http.StatusForbidden, rw.Code)
+}
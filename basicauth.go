@@ -0,0 +1,76 @@
+package controller
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// BasicAuth returns the user name and password of the request if it uses
+// HTTP Basic Authentication.
+func (b *Base) BasicAuth() (user, pass string, ok bool) {
+	if b.Request == nil {
+		return "", "", false
+	}
+	return b.Request.BasicAuth()
+}
+
+// RequireBasicAuth returns a filter protecting actions with HTTP Basic
+// Authentication. check reports whether a user name and password are valid;
+// BasicAuthUsers checks them against a fixed set of users:
+//
+//	func (*AdminController) ActionFilters() []controller.Filter {
+//		return []controller.Filter{controller.RequireBasicAuth("Admin", controller.BasicAuthUsers(admins))}
+//	}
+//
+// Requests without valid credentials fail with an HTTPError with code 401,
+// carrying a WWW-Authenticate header with realm. Once the credentials have
+// been checked, the user name is the principal of the request, as returned
+// by Base.Principal. RequireBasicAuth panics if realm contains control
+// characters, which can not be sent in a header.
+func RequireBasicAuth(realm string, check func(user, pass string) bool) Filter {
+	challenge := http.Header{"Www-Authenticate": {"Basic realm=" + quoteRealm(realm) + `, charset="UTF-8"`}}
+	return func(c Controller, rw http.ResponseWriter, r *http.Request) error {
+		user, pass, ok := r.BasicAuth()
+		if !ok || !check(user, pass) {
+			return &HTTPError{
+				Code:   http.StatusUnauthorized,
+				Err:    errors.New("Invalid credentials"),
+				Header: challenge,
+			}
+		}
+		if b, ok := c.(baser); ok && b.base().Request != nil {
+			b.base().WithValue(principalKey{}, user)
+		}
+		return nil
+	}
+}
+
+// BasicAuthUsers returns a function checking credentials against users,
+// which maps user names to passwords, for RequireBasicAuth. Passwords are
+// compared in constant time.
+func BasicAuthUsers(users map[string]string) func(user, pass string) bool {
+	hashes := make(map[string][sha256.Size]byte, len(users))
+	for user, pass := range users {
+		hashes[user] = sha256.Sum256([]byte(pass))
+	}
+	return func(user, pass string) bool {
+		want, ok := hashes[user]
+		got := sha256.Sum256([]byte(pass))
+		return subtle.ConstantTimeCompare(got[:], want[:]) == 1 && ok
+	}
+}
+
+// quoteRealm returns realm as a quoted-string of RFC 7230, in which only
+// double quotes and backslashes are escaped.
+func quoteRealm(realm string) string {
+	for _, r := range realm {
+		if (r < ' ' && r != '\t') || r == 0x7f {
+			panic(fmt.Sprintf("controller: invalid basic auth realm %q", realm))
+		}
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(realm) + `"`
+}
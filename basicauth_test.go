@@ -0,0 +1,63 @@
+package controller
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type BasicAuthController struct {
+	Base
+}
+
+func (*BasicAuthController) ActionFilters() []Filter {
+	return []Filter{RequireBasicAuth("Admin", BasicAuthUsers(map[string]string{"admin": "secret"}))}
+}
+
+func (c *BasicAuthController) Index() error {
+	user, _, _ := c.BasicAuth()
+	return c.Textf(http.StatusOK, "%s %v", user, c.Principal())
+}
+
+func TestRequireBasicAuth(t *testing.T) {
+	h := Action((*BasicAuthController).Index)
+
+	var tests = []struct {
+		user, pass string
+		code       int
+		body       string
+	}{
+		{"", "", http.StatusUnauthorized, "Invalid credentials\n"},
+		{"admin", "wrong", http.StatusUnauthorized, "Invalid credentials\n"},
+		{"other", "secret", http.StatusUnauthorized, "Invalid credentials\n"},
+		{"admin", "secret", http.StatusOK, "admin admin"},
+	}
+
+	for _, test := range tests {
+		r := httptest.NewRequest("GET", "/", nil)
+		if test.user != "" {
+			r.SetBasicAuth(test.user, test.pass)
+		}
+		rw := httptest.NewRecorder()
+		h.ServeHTTP(rw, r)
+		equals(t, test.code, rw.Code)
+		equals(t, test.body, rw.Body.String())
+		if test.code == http.StatusUnauthorized {
+			equals(t, `Basic realm="Admin", charset="UTF-8"`, rw.Header().Get("WWW-Authenticate"))
+		}
+	}
+
+	_, _, ok := (&Base{}).BasicAuth()
+	assert(t, !ok, "expected no credentials without a request\n")
+}
+
+func TestQuoteRealm(t *testing.T) {
+	equals(t, `"Admin"`, quoteRealm("Admin"))
+	equals(t, `"Say \"hi\" \\o/"`, quoteRealm(`Say "hi" \o/`))
+	equals(t, `"Zürich"`, quoteRealm("Zürich"))
+
+	defer func() {
+		assert(t, recover() != nil, "expected a realm with control characters to panic\n")
+	}()
+	RequireBasicAuth("Admin\r\nX-Injected: 1", BasicAuthUsers(nil))
+}
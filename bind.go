@@ -0,0 +1,412 @@
+package controller
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultMaxBodyBytes limits the size of request bodies. It applies to the
+// bodies read by the binders, and to every request handled by Action unless
+// the action is given its own limit with the MaxBodyBytes option. Larger
+// bodies are rejected with 413 Request Entity Too Large. Zero disables the
+// limit.
+var DefaultMaxBodyBytes int64 = 10 << 20
+
+// bodyLimit returns the body size limit that applies to r.
+func bodyLimit(r *http.Request) int64 {
+	if o, ok := r.Context().Value(optionsKey{}).(*options); ok && o.maxBodyBytes >= 0 {
+		return o.maxBodyBytes
+	}
+	return DefaultMaxBodyBytes
+}
+
+// DefaultStrictJSON makes BindJSON reject JSON bodies with fields that do not
+// exist in the value they are decoded into, and bodies with data after the
+// JSON value, instead of silently ignoring them. Unknown fields are reported
+// with 422 Unprocessable Entity, trailing data with 400 Bad Request. Strict
+// decoding can also be enabled for single actions with the StrictJSON option
+// or for single calls with BindStrictJSON.
+var DefaultStrictJSON = false
+
+// strictJSON reports whether JSON bodies of r are decoded strictly.
+func strictJSON(r *http.Request) bool {
+	if o, ok := r.Context().Value(optionsKey{}).(*options); ok && o.strictJSON != nil {
+		return *o.strictJSON
+	}
+	return DefaultStrictJSON
+}
+
+// BindJSON decodes the JSON request body into dst. It fails with an
+// HTTPError carrying the status code the failure should be reported with:
+//
+//   - 415 Unsupported Media Type if the request has a Content-Type other than
+//     JSON,
+//   - 413 Request Entity Too Large if the body exceeds DefaultMaxBodyBytes,
+//   - 400 Bad Request if the body is empty or not valid JSON,
+//   - 422 Unprocessable Entity if the JSON does not fit into dst, or if the
+//     decoded value fails validation by DefaultValidator.
+//
+// Actions can therefore simply return the error:
+//
+//	func (c *UserController) Create() error {
+//		var user User
+//		if err := c.BindJSON(&user); err != nil {
+//			return err
+//		}
+//		...
+//	}
+func (b *Base) BindJSON(dst interface{}) error {
+	return bindJSON(b.Request, dst, strictJSON(b.Request))
+}
+
+// BindStrictJSON is like BindJSON, but always decodes the body strictly as
+// described for DefaultStrictJSON.
+func (b *Base) BindStrictJSON(dst interface{}) error {
+	return bindJSON(b.Request, dst, true)
+}
+
+func bindJSON(r *http.Request, dst interface{}, strict bool) error {
+	if err := checkContentType(r, "+json", "application/json"); err != nil {
+		return err
+	}
+	if err := setDefaults(dst); err != nil {
+		return err
+	}
+	err := decodeBody(r, func(body io.Reader) error {
+		dec := json.NewDecoder(body)
+		if strict {
+			dec.DisallowUnknownFields()
+		}
+		err := dec.Decode(dst)
+		var (
+			syntaxErr *json.SyntaxError
+			typeErr   *json.UnmarshalTypeError
+		)
+		switch {
+		case errors.As(err, &syntaxErr), err == io.ErrUnexpectedEOF:
+			return &HTTPError{Code: http.StatusBadRequest, Err: fmt.Errorf("Malformed JSON: %w", err)}
+		case errors.As(err, &typeErr):
+			return &HTTPError{Code: http.StatusUnprocessableEntity, Err: err}
+		case err != nil && strings.HasPrefix(err.Error(), "json: unknown field "):
+			// encoding/json has no error type for unknown fields.
+			return &HTTPError{Code: http.StatusUnprocessableEntity, Err: err}
+		case err != nil || !strict:
+			return err
+		}
+		if _, err := dec.Token(); err != io.EOF {
+			if err == nil {
+				err = errors.New("Unexpected data after JSON value")
+			}
+			return &HTTPError{Code: http.StatusBadRequest, Err: fmt.Errorf("Malformed JSON: %w", err)}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return validate(dst)
+}
+
+// BindXML decodes the XML request body into dst. It is the XML counterpart
+// of BindJSON, accepting the application/xml and text/xml content types and
+// failing with the same status codes.
+func (b *Base) BindXML(dst interface{}) error {
+	return bindXML(b.Request, dst)
+}
+
+func bindXML(r *http.Request, dst interface{}) error {
+	if err := checkContentType(r, "+xml", "application/xml", "text/xml"); err != nil {
+		return err
+	}
+	if err := setDefaults(dst); err != nil {
+		return err
+	}
+	err := decodeBody(r, func(body io.Reader) error {
+		err := xml.NewDecoder(body).Decode(dst)
+		var (
+			syntaxErr    *xml.SyntaxError
+			unmarshalErr xml.UnmarshalError
+			numErr       *strconv.NumError
+		)
+		switch {
+		case errors.As(err, &syntaxErr), err == io.ErrUnexpectedEOF:
+			return &HTTPError{Code: http.StatusBadRequest, Err: fmt.Errorf("Malformed XML: %w", err)}
+		case errors.As(err, &unmarshalErr), errors.As(err, &numErr):
+			return &HTTPError{Code: http.StatusUnprocessableEntity, Err: err}
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return validate(dst)
+}
+
+// decodeBody calls decode with the request body, limited to bodyLimit. It
+// takes care of the failures common to all body formats, reporting empty
+// bodies with 400 Bad Request and bodies that are too large with 413 Request
+// Entity Too Large. Other errors returned by decode that are not an
+// HTTPError already are reported with 400 Bad Request.
+func decodeBody(r *http.Request, decode func(io.Reader) error) error {
+	if r.Body == nil || r.Body == http.NoBody {
+		return &HTTPError{Code: http.StatusBadRequest, Err: errors.New("Request body is empty")}
+	}
+
+	var body io.Reader = r.Body
+	if limit := bodyLimit(r); limit > 0 {
+		body = http.MaxBytesReader(nil, r.Body, limit)
+	}
+	err := decode(body)
+	var (
+		httpErr     *HTTPError
+		maxBytesErr *http.MaxBytesError
+	)
+	switch {
+	case err == nil:
+		return nil
+	case errors.As(err, &maxBytesErr):
+		return &HTTPError{Code: http.StatusRequestEntityTooLarge, Err: err}
+	case err == io.EOF:
+		return &HTTPError{Code: http.StatusBadRequest, Err: errors.New("Request body is empty")}
+	case errors.As(err, &httpErr):
+		return err
+	}
+	return &HTTPError{Code: http.StatusBadRequest, Err: err}
+}
+
+// BindForm populates the struct dst points to from the form values of an
+// application/x-www-form-urlencoded or multipart/form-data request body.
+// Fields are matched by their `form:"name"` tag, or by their Go name if they
+// have none:
+//
+//	type Signup struct {
+//		Email    string    `form:"email"`
+//		Tags     []string  `form:"tags"`
+//		Birthday time.Time `form:"birthday"`
+//		Address  struct {
+//			City string `form:"city"`
+//		} `form:"address"`
+//	}
+//
+// Slices take all values of their key, nested structs are filled from keys
+// prefixed with the name of the struct field ("address.city"), and time.Time
+// fields accept RFC 3339 as well as the formats of HTML date inputs. Values
+// that can not be converted to the type of their field or that fail
+// validation by DefaultValidator are reported with an HTTPError with code
+// 422, a body that is not a form with code 415.
+func (b *Base) BindForm(dst interface{}) error {
+	return bindForm(b.Request, dst, b.MaxMultipartMemory, b.MaxUploadBytes)
+}
+
+func bindForm(r *http.Request, dst interface{}, maxMemory, maxBytes int64) error {
+	t, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	var err error
+	switch t {
+	case "application/x-www-form-urlencoded":
+		err = r.ParseForm()
+	case "multipart/form-data":
+		err = parseMultipartForm(r, maxMemory, maxBytes)
+	default:
+		return &HTTPError{
+			Code: http.StatusUnsupportedMediaType,
+			Err:  fmt.Errorf("Unsupported Content-Type %q, expected a form", r.Header.Get("Content-Type")),
+		}
+	}
+	if err != nil {
+		return formError(err)
+	}
+	if err := setDefaults(dst); err != nil {
+		return err
+	}
+	if err := decodeValues(r.PostForm, dst, "form"); err != nil {
+		return err
+	}
+	return validate(dst)
+}
+
+// formError converts an error from parsing a form into an HTTPError.
+func formError(err error) error {
+	var (
+		httpErr     *HTTPError
+		maxBytesErr *http.MaxBytesError
+	)
+	switch {
+	case errors.As(err, &httpErr):
+		return err
+	case errors.As(err, &maxBytesErr):
+		return &HTTPError{Code: http.StatusRequestEntityTooLarge, Err: err}
+	}
+	return &HTTPError{Code: http.StatusBadRequest, Err: err}
+}
+
+// BindQuery populates the struct dst points to from the query string of the
+// request. Fields are matched by their `query:"name"` tag, or by their Go name
+// if they have none, following the same conversion and validation rules as
+// BindForm. This lets list actions declare their filters as a struct:
+//
+//	var filter struct {
+//		Status []string  `query:"status"`
+//		Since  time.Time `query:"since"`
+//		Limit  int       `query:"limit"`
+//	}
+//	if err := c.BindQuery(&filter); err != nil {
+//		return err
+//	}
+func (b *Base) BindQuery(dst interface{}) error {
+	return bindQuery(b.Request, dst)
+}
+
+func bindQuery(r *http.Request, dst interface{}) error {
+	values, err := url.ParseQuery(r.URL.RawQuery)
+	if err != nil {
+		return &HTTPError{Code: http.StatusBadRequest, Err: err}
+	}
+	if err := setDefaults(dst); err != nil {
+		return err
+	}
+	if err := decodeValues(values, dst, "query"); err != nil {
+		return err
+	}
+	return validate(dst)
+}
+
+// Bind binds dst from the request with the binder matching the request:
+// GET, HEAD and DELETE requests are bound from the query string with
+// BindQuery, other requests from their body according to its Content-Type
+// with BindJSON, BindXML or BindForm, or with a Binder registered for the
+// content type with RegisterBinder. Bodies of any other type fail with an
+// HTTPError with code 415.
+//
+// All binders first set the zero fields of dst to the value of their
+// `default` tag, so parameters missing from the request get sane values:
+//
+//	type ListUsers struct {
+//		Status []string `query:"status" default:"active,invited"`
+//		Sort   string   `query:"sort" default:"-created_at"`
+//	}
+func (b *Base) Bind(dst interface{}) error {
+	return bind(b.Request, dst, b.MaxMultipartMemory, b.MaxUploadBytes)
+}
+
+// Bind binds dst from r like Base.Bind, with the default multipart memory
+// and upload limits. It is used to bind the arguments of actions.
+func Bind(r *http.Request, dst interface{}) error {
+	return bind(r, dst, 0, 0)
+}
+
+func bind(r *http.Request, dst interface{}, maxMemory, maxBytes int64) error {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodDelete:
+		return bindQuery(r, dst)
+	}
+
+	t, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if binder := lookupBinder(t); binder != nil {
+		return bindCustom(r, dst, binder)
+	}
+
+	switch {
+	case t == "application/json" || strings.HasSuffix(t, "+json"):
+		return bindJSON(r, dst, strictJSON(r))
+	case t == "application/xml" || t == "text/xml" || strings.HasSuffix(t, "+xml"):
+		return bindXML(r, dst)
+	case t == "application/x-www-form-urlencoded" || t == "multipart/form-data":
+		return bindForm(r, dst, maxMemory, maxBytes)
+	}
+	return &HTTPError{
+		Code: http.StatusUnsupportedMediaType,
+		Err:  fmt.Errorf("Unsupported Content-Type %q", r.Header.Get("Content-Type")),
+	}
+}
+
+// Binder binds request bodies of a content type that is not supported by the
+// package itself.
+type Binder interface {
+	// Bind decodes the body of r into dst.
+	Bind(r *http.Request, dst interface{}) error
+}
+
+// BinderFunc is an adapter to use ordinary functions as a Binder.
+type BinderFunc func(r *http.Request, dst interface{}) error
+
+// Bind calls f(r, dst).
+func (f BinderFunc) Bind(r *http.Request, dst interface{}) error {
+	return f(r, dst)
+}
+
+var (
+	bindersMu sync.RWMutex
+	binders   = make(map[string]Binder)
+)
+
+// RegisterBinder registers the binder used by Base.Bind (and for the
+// arguments of actions) for request bodies of the given media type, such as
+// "application/cbor". Binders registered for the media types the package
+// supports itself take precedence over the built in binders.
+//
+// Registered binders read the body through the same size limit as the built
+// in binders, and the values they bind get the same default values and
+// validation. Registering a nil binder removes the binder for mediaType.
+func RegisterBinder(mediaType string, binder Binder) {
+	bindersMu.Lock()
+	defer bindersMu.Unlock()
+	if binder == nil {
+		delete(binders, strings.ToLower(mediaType))
+		return
+	}
+	binders[strings.ToLower(mediaType)] = binder
+}
+
+func lookupBinder(mediaType string) Binder {
+	bindersMu.RLock()
+	defer bindersMu.RUnlock()
+	return binders[mediaType]
+}
+
+func bindCustom(r *http.Request, dst interface{}, binder Binder) error {
+	if err := setDefaults(dst); err != nil {
+		return err
+	}
+	err := decodeBody(r, func(body io.Reader) error {
+		r2 := new(http.Request)
+		*r2 = *r
+		r2.Body = io.NopCloser(body)
+		return binder.Bind(r2, dst)
+	})
+	if err != nil {
+		return err
+	}
+	return validate(dst)
+}
+
+// checkContentType fails with 415 Unsupported Media Type if the request has a
+// Content-Type that is none of mediaTypes and does not end in suffix.
+// Requests without a Content-Type are accepted.
+func checkContentType(r *http.Request, suffix string, mediaTypes ...string) error {
+	header := r.Header.Get("Content-Type")
+	if header == "" {
+		return nil
+	}
+	t, _, err := mime.ParseMediaType(header)
+	if err == nil && strings.HasSuffix(t, suffix) {
+		return nil
+	}
+	for _, mediaType := range mediaTypes {
+		if err == nil && t == mediaType {
+			return nil
+		}
+	}
+	return &HTTPError{
+		Code: http.StatusUnsupportedMediaType,
+		Err:  fmt.Errorf("Unsupported Content-Type %q, expected %s", header, mediaTypes[0]),
+	}
+}
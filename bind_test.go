@@ -0,0 +1,334 @@
+package controller
+
+import (
+	"bytes"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+type bindUser struct {
+	Name string `json:"name" xml:"name"`
+	Age  int    `json:"age" xml:"age"`
+}
+
+func TestBindJSON(t *testing.T) {
+	var tests = []struct {
+		contentType string
+		body        string
+		code        int
+	}{
+		{"application/json", `{"name": "gopher", "age": 12}`, 0},
+		{"application/vnd.api+json; charset=utf-8", `{"name": "gopher", "age": 12}`, 0},
+		{"", `{"name": "gopher", "age": 12}`, 0},
+		{"text/plain", `{"name": "gopher"}`, http.StatusUnsupportedMediaType},
+		{"application/json", ``, http.StatusBadRequest},
+		{"application/json", `{"name": `, http.StatusBadRequest},
+		{"application/json", `{"name" "gopher"}`, http.StatusBadRequest},
+		{"application/json", `{"age": "twelve"}`, http.StatusUnprocessableEntity},
+		{"application/json", `"` + strings.Repeat("a", int(DefaultMaxBodyBytes)) + `"`, http.StatusRequestEntityTooLarge},
+	}
+
+	for _, test := range tests {
+		r := httptest.NewRequest("POST", "/", strings.NewReader(test.body))
+		if test.contentType != "" {
+			r.Header.Set("Content-Type", test.contentType)
+		}
+		c := &Base{Request: r}
+
+		var user bindUser
+		err := c.BindJSON(&user)
+		if test.code == 0 {
+			ok(t, err)
+			equals(t, bindUser{"gopher", 12}, user)
+			continue
+		}
+		assert(t, err != nil, "expected an error for %q\n", test.body)
+		equals(t, test.code, errorCode(err))
+	}
+}
+
+func TestBindStrictJSON(t *testing.T) {
+	var tests = []struct {
+		body string
+		code int
+	}{
+		{`{"name": "gopher", "age": 12}`, 0},
+		{`{"name": "gopher", "age": 12}` + "\n", 0},
+		{`{"name": "gopher", "age": 12, "admin": true}`, http.StatusUnprocessableEntity},
+		{`{"name": "gopher", "age": 12}{"name": "mole"}`, http.StatusBadRequest},
+		{`{"name": "gopher", "age": 12} garbage`, http.StatusBadRequest},
+	}
+
+	for _, test := range tests {
+		r := httptest.NewRequest("POST", "/", strings.NewReader(test.body))
+		c := &Base{Request: r}
+
+		var user bindUser
+		err := c.BindStrictJSON(&user)
+		if test.code == 0 {
+			ok(t, err)
+			equals(t, bindUser{"gopher", 12}, user)
+			continue
+		}
+		assert(t, err != nil, "expected an error for %q\n", test.body)
+		equals(t, test.code, errorCode(err))
+	}
+
+	// Lenient decoding stays the default.
+	r := httptest.NewRequest("POST", "/", strings.NewReader(`{"name": "gopher", "admin": true} garbage`))
+	var user bindUser
+	ok(t, (&Base{Request: r}).BindJSON(&user))
+	equals(t, "gopher", user.Name)
+}
+
+func TestBindXML(t *testing.T) {
+	var tests = []struct {
+		contentType string
+		body        string
+		code        int
+	}{
+		{"application/xml", `<user><name>gopher</name><age>12</age></user>`, 0},
+		{"text/xml; charset=utf-8", `<user><name>gopher</name><age>12</age></user>`, 0},
+		{"application/atom+xml", `<user><name>gopher</name><age>12</age></user>`, 0},
+		{"application/json", `<user></user>`, http.StatusUnsupportedMediaType},
+		{"application/xml", ``, http.StatusBadRequest},
+		{"application/xml", `<user><name>gopher</user>`, http.StatusBadRequest},
+		{"application/xml", `<user><age>twelve</age></user>`, http.StatusUnprocessableEntity},
+		{"application/xml", `<user>` + strings.Repeat("a", int(DefaultMaxBodyBytes)) + `</user>`, http.StatusRequestEntityTooLarge},
+	}
+
+	for _, test := range tests {
+		r := httptest.NewRequest("POST", "/", strings.NewReader(test.body))
+		r.Header.Set("Content-Type", test.contentType)
+
+		var user bindUser
+		err := (&Base{Request: r}).BindXML(&user)
+		if test.code == 0 {
+			ok(t, err)
+			equals(t, bindUser{"gopher", 12}, user)
+			continue
+		}
+		assert(t, err != nil, "expected an error for %q\n", test.body)
+		equals(t, test.code, errorCode(err))
+	}
+}
+
+type bindAddress struct {
+	City string `form:"city"`
+}
+
+type bindSignup struct {
+	Email    string       `form:"email"`
+	Age      int          `form:"age"`
+	Tags     []string     `form:"tags"`
+	Scores   []int        `form:"scores"`
+	Birthday time.Time    `form:"birthday"`
+	Terms    bool         `form:"terms"`
+	Address  bindAddress  `form:"address"`
+	Billing  *bindAddress `form:"billing"`
+	Nickname *string
+	Ignored  string `form:"-"`
+}
+
+func TestBindForm(t *testing.T) {
+	form := url.Values{
+		"email":        {"gopher@example.com"},
+		"age":          {"12"},
+		"tags":         {"go", "web"},
+		"scores[]":     {"1", "2"},
+		"birthday":     {"2009-11-10"},
+		"terms":        {"on"},
+		"address.city": {"Berlin"},
+		"Nickname":     {"gopher"},
+		"Ignored":      {"x"},
+		"-":            {"x"},
+	}
+	r := httptest.NewRequest("POST", "/", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	var signup bindSignup
+	ok(t, (&Base{Request: r}).BindForm(&signup))
+
+	nickname := "gopher"
+	equals(t, bindSignup{
+		Email:    "gopher@example.com",
+		Age:      12,
+		Tags:     []string{"go", "web"},
+		Scores:   []int{1, 2},
+		Birthday: time.Date(2009, 11, 10, 0, 0, 0, 0, time.UTC),
+		Terms:    true,
+		Address:  bindAddress{"Berlin"},
+		Nickname: &nickname,
+	}, signup)
+}
+
+func TestBindFormMultipart(t *testing.T) {
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	w.WriteField("email", "gopher@example.com")
+	w.WriteField("billing.city", "Paris")
+	w.Close()
+
+	r := httptest.NewRequest("POST", "/", &body)
+	r.Header.Set("Content-Type", w.FormDataContentType())
+
+	var signup bindSignup
+	ok(t, (&Base{Request: r}).BindForm(&signup))
+	equals(t, "gopher@example.com", signup.Email)
+	equals(t, &bindAddress{"Paris"}, signup.Billing)
+}
+
+func TestBindFormErrors(t *testing.T) {
+	var tests = []struct {
+		contentType string
+		body        string
+		code        int
+	}{
+		{"application/json", `{}`, http.StatusUnsupportedMediaType},
+		{"application/x-www-form-urlencoded", "age=twelve", http.StatusUnprocessableEntity},
+		{"application/x-www-form-urlencoded", "birthday=yesterday", http.StatusUnprocessableEntity},
+		{"application/x-www-form-urlencoded", "terms=maybe", http.StatusUnprocessableEntity},
+		{"application/x-www-form-urlencoded", "%zz", http.StatusBadRequest},
+	}
+
+	for _, test := range tests {
+		r := httptest.NewRequest("POST", "/", strings.NewReader(test.body))
+		r.Header.Set("Content-Type", test.contentType)
+
+		var signup bindSignup
+		err := (&Base{Request: r}).BindForm(&signup)
+		assert(t, err != nil, "expected an error for %q\n", test.body)
+		equals(t, test.code, errorCode(err))
+	}
+
+	r := httptest.NewRequest("POST", "/", strings.NewReader("email=x"))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	var notStruct string
+	err := (&Base{Request: r}).BindForm(&notStruct)
+	equals(t, http.StatusInternalServerError, errorCode(err))
+}
+
+func TestBindQuery(t *testing.T) {
+	var filter struct {
+		Status []string  `query:"status"`
+		Since  time.Time `query:"since"`
+		Limit  int       `query:"limit"`
+		Page   int
+	}
+	r := httptest.NewRequest("GET", "/?status=open&status=closed&since=2020-01-02T03:04:05Z&limit=20&Page=3", nil)
+	ok(t, (&Base{Request: r}).BindQuery(&filter))
+	equals(t, []string{"open", "closed"}, filter.Status)
+	equals(t, time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC), filter.Since)
+	equals(t, 20, filter.Limit)
+	equals(t, 3, filter.Page)
+
+	r = httptest.NewRequest("GET", "/?limit=many", nil)
+	equals(t, http.StatusUnprocessableEntity, errorCode((&Base{Request: r}).BindQuery(&filter)))
+
+	r = httptest.NewRequest("GET", "/?limit=%zz", nil)
+	equals(t, http.StatusBadRequest, errorCode((&Base{Request: r}).BindQuery(&filter)))
+}
+
+func TestBind(t *testing.T) {
+	var tests = []struct {
+		method      string
+		url         string
+		contentType string
+		body        string
+		code        int
+	}{
+		{"POST", "/", "application/json", `{"name": "gopher", "age": 12}`, 0},
+		{"PUT", "/", "application/xml", `<user><name>gopher</name><age>12</age></user>`, 0},
+		{"PATCH", "/", "application/x-www-form-urlencoded", `Name=gopher&Age=12`, 0},
+		{"GET", "/?Name=gopher&Age=12", "", "", 0},
+		{"DELETE", "/?Name=gopher&Age=12", "application/json", `{"name": "other"}`, 0},
+		{"POST", "/", "text/csv", `gopher,12`, http.StatusUnsupportedMediaType},
+		{"POST", "/", "", `{"name": "gopher", "age": 12}`, http.StatusUnsupportedMediaType},
+	}
+
+	for _, test := range tests {
+		r := httptest.NewRequest(test.method, test.url, strings.NewReader(test.body))
+		if test.contentType != "" {
+			r.Header.Set("Content-Type", test.contentType)
+		}
+
+		var user bindUser
+		err := (&Base{Request: r}).Bind(&user)
+		if test.code == 0 {
+			ok(t, err)
+			equals(t, bindUser{"gopher", 12}, user)
+			continue
+		}
+		equals(t, test.code, errorCode(err))
+	}
+}
+
+func TestBindDefaults(t *testing.T) {
+	type params struct {
+		Name    string        `json:"name" query:"name" default:"anonymous"`
+		Limit   int           `json:"limit" query:"limit" default:"20"`
+		Status  []string      `json:"status" query:"status" default:"active,invited"`
+		Timeout time.Duration `json:"-" query:"timeout" default:"5s"`
+		Address struct {
+			City string `json:"city" query:"city" default:"Berlin"`
+		} `json:"address" query:"address"`
+	}
+
+	r := httptest.NewRequest("GET", "/?limit=5", nil)
+	var p params
+	ok(t, (&Base{Request: r}).Bind(&p))
+	equals(t, "anonymous", p.Name)
+	equals(t, 5, p.Limit)
+	equals(t, []string{"active", "invited"}, p.Status)
+	equals(t, 5*time.Second, p.Timeout)
+	equals(t, "Berlin", p.Address.City)
+
+	r = httptest.NewRequest("POST", "/", strings.NewReader(`{"name": "gopher", "address": {}}`))
+	r.Header.Set("Content-Type", "application/json")
+	p = params{}
+	ok(t, (&Base{Request: r}).Bind(&p))
+	equals(t, "gopher", p.Name)
+	equals(t, 20, p.Limit)
+	equals(t, "Berlin", p.Address.City)
+
+	var bad struct {
+		Limit int `query:"limit" default:"many"`
+	}
+	r = httptest.NewRequest("GET", "/", nil)
+	equals(t, http.StatusInternalServerError, errorCode((&Base{Request: r}).Bind(&bad)))
+}
+
+func TestRegisterBinder(t *testing.T) {
+	RegisterBinder("text/x-user", BinderFunc(func(r *http.Request, dst interface{}) error {
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			return err
+		}
+		name, age, _ := strings.Cut(string(data), ":")
+		dst.(*bindUser).Name = name
+		dst.(*bindUser).Age, err = strconv.Atoi(age)
+		return err
+	}))
+	defer RegisterBinder("text/x-user", nil)
+
+	r := httptest.NewRequest("POST", "/", strings.NewReader("gopher:12"))
+	r.Header.Set("Content-Type", "text/x-user; charset=utf-8")
+	var user bindUser
+	ok(t, (&Base{Request: r}).Bind(&user))
+	equals(t, bindUser{"gopher", 12}, user)
+
+	r = httptest.NewRequest("POST", "/", strings.NewReader("gopher:"+strings.Repeat("1", int(DefaultMaxBodyBytes))))
+	r.Header.Set("Content-Type", "text/x-user")
+	equals(t, http.StatusRequestEntityTooLarge, errorCode((&Base{Request: r}).Bind(&user)))
+
+	r = httptest.NewRequest("POST", "/", strings.NewReader("gopher:old"))
+	r.Header.Set("Content-Type", "text/x-user")
+	equals(t, http.StatusBadRequest, errorCode((&Base{Request: r}).Bind(&user)))
+}
@@ -0,0 +1,86 @@
+// Package chiadapter makes controller actions play nicely with the chi router.
+//
+// chi keeps the URL parameters it matches in its own routing context. The
+// handlers in this package copy them into the request's path values so that
+// controllers can read them with Base.Param (or Request.PathValue), the same
+// way they would when mounted on an http.ServeMux:
+//
+//	r := chi.NewRouter()
+//	r.Method("GET", "/users/{id}", chiadapter.Action((*UserController).Show))
+//
+// Whole resource controllers can be mounted at once with Resource:
+//
+//	chiadapter.Resource(r, "/users", (*UserController)(nil))
+package chiadapter
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/codegangsta/controller"
+	"github.com/go-chi/chi/v5"
+)
+
+// URLParams is a chi middleware that copies the URL parameters matched by chi
+// into the path values of the request. chi only knows the parameters after a
+// route has been matched, so URLParams has to wrap the endpoint handler (for
+// instance via chi.Router.With) rather than be installed with Use.
+func URLParams(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if rctx := chi.RouteContext(r.Context()); rctx != nil {
+			for i, key := range rctx.URLParams.Keys {
+				r.SetPathValue(key, rctx.URLParams.Values[i])
+			}
+		}
+		next.ServeHTTP(rw, r)
+	})
+}
+
+// Action is the chi counterpart of controller.Action. The returned handler
+// exposes the chi URL parameters to the controller before invoking the action.
+func Action(action interface{}, opts ...controller.Option) http.Handler {
+	return URLParams(controller.Action(action, opts...))
+}
+
+// resourceRoutes maps the conventional resource action names to the method
+// and path (relative to the resource pattern) they are served on.
+var resourceRoutes = []struct {
+	action string
+	method string
+	path   string
+}{
+	{"Index", http.MethodGet, "/"},
+	{"Create", http.MethodPost, "/"},
+	{"Show", http.MethodGet, "/{id}"},
+	{"Update", http.MethodPut, "/{id}"},
+	{"Update", http.MethodPatch, "/{id}"},
+	{"Delete", http.MethodDelete, "/{id}"},
+}
+
+// Resource mounts a resource controller on r under pattern. Every
+// conventional action the controller implements is routed as follows:
+//
+//	GET    /pattern       Index
+//	POST   /pattern       Create
+//	GET    /pattern/{id}  Show
+//	PUT    /pattern/{id}  Update
+//	PATCH  /pattern/{id}  Update
+//	DELETE /pattern/{id}  Delete
+//
+// Actions the controller does not implement are left unrouted, as are
+// methods with one of these names that are not actions. The c argument is
+// only used for its type, so a typed nil pointer is fine. opts apply to
+// every action.
+func Resource(r chi.Router, pattern string, c controller.Controller, opts ...controller.Option) {
+	t := reflect.TypeOf(c)
+	r.Route(strings.TrimSuffix(pattern, "/"), func(r chi.Router) {
+		for _, route := range resourceRoutes {
+			m, ok := t.MethodByName(route.action)
+			if !ok || controller.CheckAction(m.Func.Interface()) != nil {
+				continue
+			}
+			r.Method(route.method, route.path, Action(m.Func.Interface(), opts...))
+		}
+	})
+}
@@ -0,0 +1,81 @@
+package chiadapter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/codegangsta/controller"
+	"github.com/go-chi/chi/v5"
+)
+
+type UserController struct {
+	controller.Base
+}
+
+func (c *UserController) Index() error {
+	c.ResponseWriter.Write([]byte("index"))
+	return nil
+}
+
+func (c *UserController) Show() error {
+	c.ResponseWriter.Write([]byte("show " + c.Request.PathValue("id")))
+	return nil
+}
+
+// Delete is not an action, so Resource does not route it.
+func (c *UserController) Delete(id int) {
+}
+
+func TestResource(t *testing.T) {
+	r := chi.NewRouter()
+	Resource(r, "/users", (*UserController)(nil))
+
+	var tests = []struct {
+		method string
+		path   string
+		code   int
+		body   string
+	}{
+		{"GET", "/users", http.StatusOK, "index"},
+		{"GET", "/users/42", http.StatusOK, "show 42"},
+		{"DELETE", "/users/42", http.StatusMethodNotAllowed, ""},
+		{"POST", "/users", http.StatusMethodNotAllowed, ""},
+	}
+
+	for _, test := range tests {
+		rw := httptest.NewRecorder()
+		r.ServeHTTP(rw, httptest.NewRequest(test.method, test.path, nil))
+		if rw.Code != test.code {
+			t.Errorf("%s %s: expected status %d, got %d", test.method, test.path, test.code, rw.Code)
+		}
+		if test.body != "" && rw.Body.String() != test.body {
+			t.Errorf("%s %s: expected body %q, got %q", test.method, test.path, test.body, rw.Body.String())
+		}
+	}
+}
+
+func TestAction(t *testing.T) {
+	r := chi.NewRouter()
+	r.Method("GET", "/users/{id}", Action((*UserController).Show))
+
+	rw := httptest.NewRecorder()
+	r.ServeHTTP(rw, httptest.NewRequest("GET", "/users/7", nil))
+	if rw.Body.String() != "show 7" {
+		t.Errorf("expected body %q, got %q", "show 7", rw.Body.String())
+	}
+}
+
+func TestActionOptions(t *testing.T) {
+	deny := func(c controller.Controller, rw http.ResponseWriter, r *http.Request) error {
+		return &controller.HTTPError{Code: http.StatusForbidden}
+	}
+	r := chi.NewRouter()
+	Resource(r, "/users", (*UserController)(nil), controller.UseFilters(deny))
+
+	rw := httptest.NewRecorder()
+	r.ServeHTTP(rw, httptest.NewRequest("GET", "/users/7", nil))
+	if rw.Code != http.StatusForbidden {
+		t.Errorf("expected the options to apply, got %d", rw.Code)
+	}
+}
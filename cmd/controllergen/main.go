@@ -0,0 +1,299 @@
+// Controllergen generates reflection-free http.Handler constructors for the
+// actions of controllers.
+//
+// Given the controller types of a package, it writes a function for every
+// action, named after the controller and the action, that returns the same
+// handler as controller.Action but dispatches with ordinary method calls:
+//
+//	//go:generate controllergen -type UserController,PostController
+//
+//	http.Handle("GET /users/{id}", UserControllerShow())
+//	http.Handle("POST /users", UserControllerCreate(controller.MaxBodyBytes(1<<20)))
+//
+// Actions are the exported methods declared on a pointer to the controller
+// type that return an error and take either no argument or a pointer to a
+// struct, except for the methods of the Controller interface. Methods
+// promoted from embedded structs are not considered. Controllers with a New
+// method implementing controller.Constructor are constructed with it.
+//
+// Usage:
+//
+//	controllergen -type T[,T...] [-output file] [dir]
+//
+// The output defaults to controllers_gen.go in dir, which defaults to the
+// current directory.
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("controllergen: ")
+
+	types := flag.String("type", "", "comma-separated list of controller type names; must be set")
+	output := flag.String("output", "controllers_gen.go", "output file name, relative to the package directory")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: controllergen -type T[,T...] [-output file] [dir]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if *types == "" || flag.NArg() > 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	dir := "."
+	if flag.NArg() == 1 {
+		dir = flag.Arg(0)
+	}
+
+	src, err := generate(dir, strings.Split(*types, ","), *output)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, *output), src, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// action is an action found on a controller type.
+type action struct {
+	Controller string
+	Method     string
+	// Param is the struct type of the argument of the action as written in
+	// the source, such as "CreateUser" or "pb.CreateUser", or empty.
+	Param string
+}
+
+// skipped lists the methods of the controller.Controller interface, which
+// are never actions.
+var skipped = map[string]bool{"Init": true, "Destroy": true, "Error": true}
+
+// generate parses the package in dir and returns the source of the handlers
+// for the actions of types. The file named output is ignored while parsing,
+// so that generated code from a previous run does not get in the way.
+func generate(dir string, types []string, output string) ([]byte, error) {
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, dir, func(info os.FileInfo) bool {
+		return !strings.HasSuffix(info.Name(), "_test.go") && info.Name() != output
+	}, 0)
+	if err != nil {
+		return nil, err
+	}
+	if len(pkgs) != 1 {
+		return nil, fmt.Errorf("Expected a single package in %s, found %d", dir, len(pkgs))
+	}
+
+	wanted := make(map[string]bool)
+	for _, t := range types {
+		wanted[strings.TrimSpace(t)] = true
+	}
+
+	var (
+		pkgName string
+		actions []action
+		imports = make(map[string]string)
+		found   = make(map[string]bool)
+		// constructed records the types implementing controller.Constructor.
+		constructed = make(map[string]bool)
+	)
+	for name, pkg := range pkgs {
+		pkgName = name
+		for _, file := range pkg.Files {
+			for _, decl := range file.Decls {
+				if gen, ok := decl.(*ast.GenDecl); ok && gen.Tok == token.TYPE {
+					for _, spec := range gen.Specs {
+						if name := spec.(*ast.TypeSpec).Name.Name; wanted[name] {
+							found[name] = true
+						}
+					}
+				}
+
+				fn, ok := decl.(*ast.FuncDecl)
+				if !ok {
+					continue
+				}
+				if name, ok := parseConstructor(fn); ok {
+					constructed[name] = true
+				}
+				a, ok := parseAction(fn)
+				if !ok || !wanted[a.Controller] {
+					continue
+				}
+				if sel := strings.SplitN(a.Param, ".", 2); len(sel) == 2 {
+					importPath, err := lookupImport(file, sel[0])
+					if err != nil {
+						return nil, fmt.Errorf("%s: %v", fset.Position(fn.Pos()), err)
+					}
+					imports[sel[0]] = importPath
+				}
+				actions = append(actions, a)
+			}
+		}
+	}
+	for name := range wanted {
+		if !found[name] {
+			return nil, fmt.Errorf("Type %s not found in %s", name, dir)
+		}
+	}
+	if len(actions) == 0 {
+		return nil, errors.New("No actions found")
+	}
+	sort.Slice(actions, func(i, j int) bool {
+		if actions[i].Controller != actions[j].Controller {
+			return actions[i].Controller < actions[j].Controller
+		}
+		return actions[i].Method < actions[j].Method
+	})
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by controllergen; DO NOT EDIT.\n\n")
+	fmt.Fprintf(&buf, "package %s\n\n", pkgName)
+	fmt.Fprintf(&buf, "import (\n\t\"net/http\"\n\n\t\"github.com/codegangsta/controller\"\n")
+	names := make([]string, 0, len(imports))
+	for name := range imports {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(&buf, "\t%s %q\n", name, imports[name])
+	}
+	fmt.Fprintf(&buf, ")\n")
+
+	for _, a := range actions {
+		fmt.Fprintf(&buf, "\n// %s%s returns a handler for (*%s).%s, equivalent to\n", a.Controller, a.Method, a.Controller, a.Method)
+		fmt.Fprintf(&buf, "// controller.Action((*%s).%s, opts...) but without reflection.\n", a.Controller, a.Method)
+		fmt.Fprintf(&buf, "func %s%s(opts ...controller.Option) http.Handler {\n", a.Controller, a.Method)
+		fmt.Fprintf(&buf, "\treturn controller.Handler(\n")
+		if constructed[a.Controller] {
+			fmt.Fprintf(&buf, "\t\tnew(%s).New,\n", a.Controller)
+		} else {
+			fmt.Fprintf(&buf, "\t\tfunc() controller.Controller { return new(%s) },\n", a.Controller)
+		}
+		fmt.Fprintf(&buf, "\t\tfunc(c controller.Controller, r *http.Request) error {\n")
+		if a.Param == "" {
+			fmt.Fprintf(&buf, "\t\t\treturn c.(*%s).%s()\n", a.Controller, a.Method)
+		} else {
+			fmt.Fprintf(&buf, "\t\t\tin := new(%s)\n", a.Param)
+			fmt.Fprintf(&buf, "\t\t\tif err := controller.Bind(r, in); err != nil {\n\t\t\t\treturn err\n\t\t\t}\n")
+			fmt.Fprintf(&buf, "\t\t\treturn c.(*%s).%s(in)\n", a.Controller, a.Method)
+		}
+		fmt.Fprintf(&buf, "\t\t},\n\t\tappend([]controller.Option{controller.ActionName(%q)}, opts...)...,\n\t)\n}\n", a.Method)
+	}
+	return format.Source(buf.Bytes())
+}
+
+// parseAction reports whether fn is an action, and returns it if so.
+func parseAction(fn *ast.FuncDecl) (action, bool) {
+	if fn.Recv == nil || len(fn.Recv.List) != 1 || !fn.Name.IsExported() || skipped[fn.Name.Name] {
+		return action{}, false
+	}
+	recv, ok := fn.Recv.List[0].Type.(*ast.StarExpr)
+	if !ok {
+		return action{}, false
+	}
+	controller, ok := recv.X.(*ast.Ident)
+	if !ok {
+		return action{}, false
+	}
+
+	results := fn.Type.Results
+	if results == nil || len(results.List) != 1 || len(results.List[0].Names) > 1 {
+		return action{}, false
+	}
+	if ident, ok := results.List[0].Type.(*ast.Ident); !ok || ident.Name != "error" {
+		return action{}, false
+	}
+
+	a := action{Controller: controller.Name, Method: fn.Name.Name}
+	params := fn.Type.Params.List
+	switch {
+	case len(params) == 0:
+		return a, true
+	case len(params) > 1 || len(params[0].Names) > 1:
+		return action{}, false
+	}
+	star, ok := params[0].Type.(*ast.StarExpr)
+	if !ok {
+		return action{}, false
+	}
+	switch t := star.X.(type) {
+	case *ast.Ident:
+		a.Param = t.Name
+	case *ast.SelectorExpr:
+		pkg, ok := t.X.(*ast.Ident)
+		if !ok {
+			return action{}, false
+		}
+		a.Param = pkg.Name + "." + t.Sel.Name
+	default:
+		return action{}, false
+	}
+	return a, true
+}
+
+// parseConstructor reports whether fn is the New method of a controller
+// implementing controller.Constructor, and returns the controller type if so.
+func parseConstructor(fn *ast.FuncDecl) (string, bool) {
+	if fn.Recv == nil || len(fn.Recv.List) != 1 || fn.Name.Name != "New" || len(fn.Type.Params.List) != 0 {
+		return "", false
+	}
+	recv, ok := fn.Recv.List[0].Type.(*ast.StarExpr)
+	if !ok {
+		return "", false
+	}
+	controller, ok := recv.X.(*ast.Ident)
+	if !ok {
+		return "", false
+	}
+	results := fn.Type.Results
+	if results == nil || len(results.List) != 1 || len(results.List[0].Names) > 1 {
+		return "", false
+	}
+	if sel, ok := results.List[0].Type.(*ast.SelectorExpr); !ok || sel.Sel.Name != "Controller" {
+		return "", false
+	}
+	return controller.Name, true
+}
+
+// lookupImport returns the path of the package imported as name by file.
+// Imports without an explicit name are matched by the last element of their
+// path, ignoring major version suffixes such as "/v2".
+func lookupImport(file *ast.File, name string) (string, error) {
+	for _, spec := range file.Imports {
+		importPath, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			return "", err
+		}
+		if spec.Name != nil {
+			if spec.Name.Name == name {
+				return importPath, nil
+			}
+			continue
+		}
+		base := path.Base(importPath)
+		if strings.HasPrefix(base, "v") && len(base) > 1 && strings.Trim(base[1:], "0123456789") == "" {
+			base = path.Base(path.Dir(importPath))
+		}
+		if base == name {
+			return importPath, nil
+		}
+	}
+	return "", fmt.Errorf("No import found for package %s", name)
+}
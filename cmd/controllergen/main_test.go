@@ -0,0 +1,42 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+func TestGenerate(t *testing.T) {
+	dir := filepath.Join("testdata", "users")
+	src, err := generate(dir, []string{"UserController", "PostController"}, "controllers_gen.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	golden := filepath.Join(dir, "controllers_gen.golden")
+	if *update {
+		if err := os.WriteFile(golden, src, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(src) != string(want) {
+		t.Errorf("generated code does not match %s:\n%s", golden, src)
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	dir := filepath.Join("testdata", "users")
+	if _, err := generate(dir, []string{"MissingController"}, "controllers_gen.go"); err == nil {
+		t.Error("expected an error for a missing type")
+	}
+	if _, err := generate(dir, []string{"CreateUser"}, "controllers_gen.go"); err == nil {
+		t.Error("expected an error for a type without actions")
+	}
+}
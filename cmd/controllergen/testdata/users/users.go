@@ -0,0 +1,40 @@
+package users
+
+import (
+	"net/http"
+
+	pb "example.com/api/users/v1"
+	"github.com/codegangsta/controller"
+)
+
+type UserController struct {
+	controller.Base
+}
+
+type CreateUser struct {
+	Name string `json:"name" validate:"required"`
+}
+
+func (c *UserController) Init(rw http.ResponseWriter, r *http.Request) error {
+	return c.Base.Init(rw, r)
+}
+
+func (c *UserController) Index() error { return nil }
+
+func (c *UserController) Create(in *CreateUser) error { return nil }
+
+func (c *UserController) Import(in *pb.ImportUsers) error { return nil }
+
+func (c *UserController) helper() error { return nil }
+
+func (c *UserController) Count() int { return 0 }
+
+func (c *UserController) Rename(name string) error { return nil }
+
+type PostController struct {
+	controller.Base
+}
+
+func (c *PostController) Show() (err error) { return nil }
+
+func (*PostController) New() controller.Controller { return &PostController{} }
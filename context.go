@@ -0,0 +1,132 @@
+package controller
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Context returns the context of the request, so actions can hand it to
+// context-aware libraries:
+//
+//	rows, err := db.QueryContext(c.Context(), "SELECT ...")
+//
+// It returns context.Background() if the controller has no request.
+func (b *Base) Context() context.Context {
+	if b.Request == nil {
+		return context.Background()
+	}
+	return b.Request.Context()
+}
+
+// WithValue replaces the context of the request with one carrying value for
+// key, as context.WithValue. Since Request is replaced as well, the value is
+// visible to everything the request is handed to afterwards.
+func (b *Base) WithValue(key, value interface{}) {
+	b.Request = b.Request.WithContext(context.WithValue(b.Context(), key, value))
+}
+
+// Deadline returns the time when the request context will be canceled, if
+// it has a deadline.
+func (b *Base) Deadline() (deadline time.Time, ok bool) {
+	return b.Context().Deadline()
+}
+
+// Defer registers fn to be called after Destroy, when the request has been
+// served. Functions are called in the reverse order they were registered in,
+// like deferred calls, which lets Init and mixins release what they acquire
+// right where they acquire it:
+//
+//	sub := c.Events.Subscribe(topic)
+//	c.Defer(sub.Close)
+//
+// For actions with the AbortOnCancel option, the functions are called as
+// soon as the client goes away instead, and functions registered afterwards
+// are called right away.
+func (b *Base) Defer(fn func()) {
+	if a := b.abort; a != nil {
+		a.mu.Lock()
+		if a.done {
+			a.mu.Unlock()
+			fn()
+			return
+		}
+		defer a.mu.Unlock()
+	}
+	b.deferred = append(b.deferred, fn)
+}
+
+// AbortOnCancel makes an action abort the work of its controllers when the
+// context of the request is canceled, usually because the client went away,
+// rather than letting long-running actions run to completion. The context
+// returned by Base.Context is done, and the functions registered with
+// Base.Defer are called from another goroutine at that moment, so they can
+// close the connections, subscriptions or jobs the action is blocked on.
+// Destroy is still called once the action has returned, since the
+// controller can not be destroyed while it is in use.
+func AbortOnCancel() Option {
+	return func(o *options) {
+		o.abort = true
+	}
+}
+
+// abortState tracks whether the deferred functions of a controller have been
+// called, for actions with the AbortOnCancel option.
+type abortState struct {
+	mu   sync.Mutex
+	done bool
+	stop func() bool
+}
+
+// abortOnCancel arranges for the deferred functions of c to be called when
+// ctx is canceled.
+func abortOnCancel(c Controller, ctx context.Context) {
+	b, ok := c.(baser)
+	if !ok {
+		return
+	}
+	base := b.base()
+	a := &abortState{}
+	base.abort = a
+	a.stop = context.AfterFunc(ctx, func() {
+		a.mu.Lock()
+		defer a.mu.Unlock()
+		if !a.done {
+			a.done = true
+			base.callDeferred()
+		}
+	})
+}
+
+// settle waits for the deferred functions called because the request was
+// canceled to return, and prevents them from being called later on.
+func (b *Base) settle() {
+	if a := b.abort; a != nil {
+		a.stop()
+		a.mu.Lock()
+		a.mu.Unlock()
+	}
+}
+
+// runDeferred calls the deferred functions, unless they have been called
+// already.
+func (b *Base) runDeferred() {
+	if a := b.abort; a != nil {
+		a.mu.Lock()
+		done := a.done
+		a.done = true
+		a.mu.Unlock()
+		if done {
+			return
+		}
+	}
+	b.callDeferred()
+}
+
+func (b *Base) callDeferred() {
+	deferred := b.deferred
+	b.deferred = nil
+	for i := len(deferred) - 1; i >= 0; i-- {
+		deferred[i]()
+	}
+}
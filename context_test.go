@@ -0,0 +1,108 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type ctxKey struct{}
+
+type ContextController struct {
+	Base
+}
+
+func (c *ContextController) Init(rw http.ResponseWriter, r *http.Request) error {
+	c.Base.Init(rw, r)
+	c.WithValue(ctxKey{}, "tenant-1")
+	return nil
+}
+
+func (c *ContextController) Index() error {
+	tenant, _ := c.Context().Value(ctxKey{}).(string)
+	return c.Text(http.StatusOK, tenant)
+}
+
+func TestContext(t *testing.T) {
+	equals(t, context.Background(), (&Base{}).Context())
+
+	rw := httptest.NewRecorder()
+	Action((*ContextController).Index).ServeHTTP(rw, httptest.NewRequest("GET", "/", nil))
+	equals(t, "tenant-1", rw.Body.String())
+
+	deadline := time.Now().Add(time.Minute)
+	ctx, cancel := context.WithDeadline(context.Background(), deadline)
+	defer cancel()
+	c := &Base{Request: httptest.NewRequest("GET", "/", nil).WithContext(ctx)}
+	got, ok := c.Deadline()
+	assert(t, ok, "expected a deadline\n")
+	equals(t, deadline, got)
+}
+
+type DeferController struct {
+	Base
+	events *[]string
+}
+
+func (c *DeferController) Init(rw http.ResponseWriter, r *http.Request) error {
+	c.Base.Init(rw, r)
+	c.Defer(func() { *c.events = append(*c.events, "deferred 1") })
+	c.Defer(func() { *c.events = append(*c.events, "deferred 2") })
+	return nil
+}
+
+func (c *DeferController) Destroy() {
+	*c.events = append(*c.events, "destroy")
+}
+
+func (c *DeferController) Index() error {
+	return nil
+}
+
+func TestDefer(t *testing.T) {
+	var events []string
+	factory := Factory(func() Controller { return &DeferController{events: &events} })
+	Action((*DeferController).Index, factory).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	equals(t, []string{"destroy", "deferred 2", "deferred 1"}, events)
+}
+
+type AbortController struct {
+	Base
+	events  *[]string
+	started chan struct{}
+}
+
+func (c *AbortController) Destroy() {
+	*c.events = append(*c.events, "destroy")
+}
+
+// Wait blocks until the subscription it registers with Defer is closed.
+func (c *AbortController) Wait() error {
+	sub := make(chan struct{})
+	c.Defer(func() {
+		*c.events = append(*c.events, "deferred")
+		close(sub)
+	})
+	close(c.started)
+	<-sub
+	*c.events = append(*c.events, "aborted")
+	return c.Context().Err()
+}
+
+func TestAbortOnCancel(t *testing.T) {
+	var events []string
+	started := make(chan struct{})
+	h := Action((*AbortController).Wait, AbortOnCancel(), Factory(func() Controller {
+		return &AbortController{events: &events, started: started}
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-started
+		cancel()
+	}()
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil).WithContext(ctx))
+	equals(t, []string{"deferred", "aborted", "destroy"}, events)
+}
@@ -30,9 +30,15 @@
 package controller
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"io"
+	"log/slog"
 	"net/http"
 	"reflect"
+	"sync"
+	"time"
 )
 
 // Controller is an interface for defining a web controller that can be
@@ -60,6 +66,23 @@ type Controller interface {
 type Base struct {
 	Request        *http.Request
 	ResponseWriter http.ResponseWriter
+
+	// MaxMultipartMemory is the number of bytes of a multipart form that are
+	// kept in memory while parsing it, the remainder is stored in temporary
+	// files on disk. It defaults to 32MB.
+	MaxMultipartMemory int64
+	// MaxUploadBytes limits the total size of multipart request bodies, and
+	// thereby the disk space taken by uploaded files. Larger requests are
+	// rejected with 413 Request Entity Too Large. It is unlimited if zero.
+	MaxUploadBytes int64
+	// Logger is the logger of the request returned by Log. It is created
+	// when Log is first called, unless Init sets it.
+	Logger *slog.Logger
+
+	written  bool
+	store    map[string]interface{}
+	deferred []func()
+	abort    *abortState
 }
 
 // Init initializes the base controller with a ResponseWriter and Request.
@@ -79,13 +102,274 @@ func (b *Base) Error(code int, error string) {
 	http.Error(b.ResponseWriter, error, code)
 }
 
+// Option configures the http.Handler returned by Action.
+type Option func(*options)
+
+type options struct {
+	maxBodyBytes int64
+	strictJSON   *bool
+	pool         bool
+	injector     Injector
+	factory      func() Controller
+	app          *App
+	abort        bool
+	timeout      time.Duration
+	middleware   []func(http.Handler) http.Handler
+	filters      []Filter
+	name         string
+}
+
+func newOptions(opts []Option) options {
+	o := options{maxBodyBytes: -1}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// optionsKey is the context key under which the options of an action are
+// made available to the binders, Base.App and Get.
+type optionsKey struct{}
+
+// prepare applies the options to a request before it is handed to a
+// controller. It returns the request to use and the body size limit in
+// effect for it. The request is only copied if it needs to carry options
+// for the binders, so actions without options serve requests without
+// allocating.
+func (o *options) prepare(rw http.ResponseWriter, r *http.Request) (*http.Request, int64) {
+	limit := DefaultMaxBodyBytes
+	if o.maxBodyBytes >= 0 {
+		limit = o.maxBodyBytes
+	}
+	if o.maxBodyBytes >= 0 || o.strictJSON != nil || o.injector != nil || o.app != nil {
+		r = r.WithContext(context.WithValue(r.Context(), optionsKey{}, o))
+	}
+	if limit > 0 && r.Body != nil && r.Body != http.NoBody {
+		r.Body = http.MaxBytesReader(rw, r.Body, limit)
+	}
+	return r, limit
+}
+
+// MaxBodyBytes limits the size of the request bodies accepted by an action,
+// overriding DefaultMaxBodyBytes. Requests announcing a larger body are
+// rejected with 413 Request Entity Too Large through the Error method of the
+// controller before the action is invoked, and reading beyond the limit
+// fails. Zero disables the limit.
+func MaxBodyBytes(n int64) Option {
+	return func(o *options) {
+		o.maxBodyBytes = n
+	}
+}
+
+// StrictJSON enables or disables strict decoding of JSON request bodies for
+// an action, overriding DefaultStrictJSON. It applies to the argument of the
+// action as well as to the binders called by the action.
+func StrictJSON(strict bool) Option {
+	return func(o *options) {
+		o.strictJSON = &strict
+	}
+}
+
+// Factory makes an action construct its controllers with factory instead of
+// allocating zero values, so that applications can pre-populate fields or
+// hand out decorated or reused instances:
+//
+//	controller.Action((*UserController).Show, controller.Factory(func() controller.Controller {
+//		return &UserController{Users: users, Logger: logger}
+//	}))
+//
+// The factory must return a pointer to the controller type of the action.
+// Requests for which it does not fail with 500 Internal Server Error.
+// Handlers created with Handler, ActionOf and ActionWith use the factory in
+// place of their own constructor.
+func Factory(factory func() Controller) Option {
+	return func(o *options) {
+		o.factory = factory
+	}
+}
+
+// Constructor is implemented by controllers that need to be constructed by
+// a function of their own rather than allocated as zero values, for example
+// because they have unexported fields, interface fields or defaults to set:
+//
+//	func (*UserController) New() controller.Controller {
+//		return &UserController{pageSize: 20, clock: realClock{}}
+//	}
+//
+// Actions call New on a zero value of the controller type for every request.
+// It must return a pointer to the controller type, like a Factory, which
+// takes precedence over it.
+type Constructor interface {
+	New() Controller
+}
+
+// constructor returns the function that constructs the controllers of type
+// *t for an action: the Factory option if set, then the New method of
+// Constructor controllers, and finally reflect.New.
+func (o *options) constructor(t reflect.Type) func() Controller {
+	if o.factory != nil {
+		return o.factory
+	}
+	if c, ok := reflect.New(t).Interface().(Constructor); ok {
+		return c.New
+	}
+	return func() Controller {
+		return reflect.New(t).Interface().(Controller)
+	}
+}
+
+// Resetter is implemented by controllers that can be reused for another
+// request. Reset must return the controller to the state of a newly
+// allocated one, including the fields of embedded structs such as Base.
+// Assigning the zero value is usually the simplest way to do so:
+//
+//	func (c *UserController) Reset() {
+//		*c = UserController{}
+//	}
+type Resetter interface {
+	Reset()
+}
+
+// PoolControllers makes an action recycle its controllers through a
+// sync.Pool instead of allocating one for every request, for hot endpoints
+// where the allocation shows up in profiles. Only controllers implementing
+// Resetter are pooled: after the action and Destroy have run, Reset is
+// called and the controller is returned to the pool. The controller must not
+// be used by anything outliving the request, such as goroutines started by
+// the action.
+func PoolControllers() Option {
+	return func(o *options) {
+		o.pool = true
+	}
+}
+
+// recycler returns the functions that obtain the controller for a request
+// and release it once the request has been served. Controllers are taken
+// from a pool if the PoolControllers option is set, and allocated with
+// newController otherwise.
+func (o *options) recycler(newController func() Controller) (get func() Controller, put func(Controller)) {
+	if !o.pool {
+		return newController, func(Controller) {}
+	}
+	pool := &sync.Pool{New: func() interface{} { return newController() }}
+	get = func() Controller {
+		return pool.Get().(Controller)
+	}
+	put = func(c Controller) {
+		if r, ok := c.(Resetter); ok {
+			r.Reset()
+			pool.Put(c)
+		}
+	}
+	return get, put
+}
+
+// Finisher is implemented by controllers that need to know how the request
+// ended before Destroy is called, for example to commit or roll back a
+// transaction. Finish is called with the error returned by Init, the binding
+// of the argument or the action, which has already been reported through
+// Error, or with nil if the action succeeded. If the action panics, Finish
+// is called with a *PanicError and the panic continues once Destroy has
+// returned.
+type Finisher interface {
+	Finish(err error)
+}
+
+// PanicError is the error a Finisher is finished with when its action
+// panics.
+type PanicError struct {
+	Value interface{}
+}
+
+// Error returns the panic value formatted as a string.
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("Action panicked: %v", e.Value)
+}
+
+// finish ends the lifecycle of the controller c: it calls Finish if c is a
+// Finisher, Destroy, closes the request scoped values injected into c, runs
+// the functions registered with Base.Defer, and calls put. p is the value
+// recovered from a panic of the action, which is resumed afterwards.
+func finish(c Controller, err error, p interface{}, put func(Controller), closers []io.Closer) {
+	if f, ok := c.(Finisher); ok {
+		if p != nil {
+			err = &PanicError{Value: p}
+		}
+		f.Finish(err)
+	}
+	b, _ := c.(baser)
+	if b != nil {
+		b.base().settle()
+	}
+	c.Destroy()
+	closeAll(closers)
+	if b != nil {
+		b.base().runDeferred()
+	}
+	put(c)
+	if p != nil {
+		panic(p)
+	}
+}
+
+// HTTPError is an error that carries the HTTP status code it should be
+// reported with. When Init or an action returns an HTTPError (or an error
+// wrapping one), its Code is passed to the Error method of the controller
+// instead of 500 Internal Server Error. Header holds headers to send with
+// the error response, such as Retry-After, since the headers set before the
+// error are discarded with the rest of the response.
+type HTTPError struct {
+	Code   int
+	Err    error
+	Header http.Header
+}
+
+// Error returns the message of the wrapped error, or the status text of Code
+// if there is none.
+func (e *HTTPError) Error() string {
+	if e.Err == nil {
+		return http.StatusText(e.Code)
+	}
+	return e.Err.Error()
+}
+
+// Unwrap returns the wrapped error.
+func (e *HTTPError) Unwrap() error {
+	return e.Err
+}
+
+// ErrorHandler is implemented by controllers that report the errors of Init
+// and their actions themselves, instead of through Error with the status
+// code and message of the error. It lets API controllers render structured
+// responses, such as the fields listed by ValidationErrors:
+//
+//	func (c *APIController) HandleError(err error) {
+//		var fields controller.ValidationErrors
+//		if errors.As(err, &fields) {
+//			c.JSON(controller.ErrorStatus(err), map[string]interface{}{"errors": fields})
+//			return
+//		}
+//		c.Error(controller.ErrorStatus(err), err.Error())
+//	}
+type ErrorHandler interface {
+	HandleError(err error)
+}
+
+// ErrorStatus returns the status code err should be reported with: the code
+// of the HTTPError it is or wraps, or else 500 Internal Server Error.
+func ErrorStatus(err error) int {
+	return errorCode(err)
+}
+
 // Action takes a method expression and translates it into a callable
 // http.Handler which, when called:
 //
 // 		1. Constructs a controller instance
 // 		2. Initializes the controller via the Init function
-// 		3. Invokes the Action method referenced by the method expression
-// 		4. Calls destroy on the controller
+// 		3. Authenticates and authorizes the request if the controller is an
+// 		   Authenticator or Authorizer, and runs the filters of the action
+// 		4. Invokes the Action method referenced by the method expression
+// 		5. Calls destroy on the controller
 //
 // This flow allows for similar logic to be cleanly reused while data is no
 // longer shared between requests. This is because a new Controller instance
@@ -98,28 +382,120 @@ func (b *Base) Error(code int, error string) {
 //
 // Where MyController is an implementor of the Controller interface and Index
 // is a method on MyController that takes no arguments and returns an err
-func Action(action interface{}) http.Handler {
+//
+// Actions may also take a pointer to a struct as their only argument. The
+// struct is then bound from the request after Init, following the rules of
+// Base.Bind. Binding and validation errors are passed to the Error method of
+// the controller and the action is not invoked:
+//
+// 		func (c *UserController) Create(in *CreateUserRequest) error
+//
+// The behavior of the returned handler can be adjusted with options:
+//
+// 		controller.Action((*UploadController).Create, controller.MaxBodyBytes(100<<20))
+// 		controller.Action((*UserController).Create, controller.StrictJSON(true))
+// 		controller.Action((*SearchController).Index, controller.Use(rateLimit))
+// 		controller.Action((*ReportController).Show, controller.Timeout(5*time.Second))
+//
+// The ResponseWriter controllers are initialized with is a *ResponseWriter,
+// which buffers the beginning of the response. Errors returned by the action
+// after it has started writing therefore replace the partial response instead
+// of being appended to it.
+func Action(action interface{}, opts ...Option) http.Handler {
 	val := reflect.ValueOf(action)
 	t, err := controllerType(val)
 	if err != nil {
 		panic(err)
 	}
+	param := paramType(val.Type())
+	call := newInvoker(val)
+
+	o := newOptions(append([]Option{ActionName(actionName(val))}, opts...))
+	newController := o.constructor(t)
+	_, constructed := reflect.New(t).Interface().(Constructor)
+	checkType := o.factory != nil || constructed
+	get, put := o.recycler(newController)
+	zero := reflect.New(t).Interface().(Controller)
+	filters := o.filtersFor(zero)
 
-	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
-		v := reflect.New(t)
-		c := v.Interface().(Controller)
-		err = c.Init(rw, r)
-		defer c.Destroy()
+	return withMiddleware(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		r, limit := o.prepare(rw, r)
+		if o.timeout > 0 {
+			var cancel context.CancelFunc
+			r, cancel = o.withTimeout(r)
+			defer cancel()
+		}
+		w := newResponseWriter(rw)
+		defer w.release()
+
+		c := get()
+		if checkType && reflect.TypeOf(c) != reflect.PtrTo(t) {
+			http.Error(w, fmt.Sprintf("Controller constructor returned %T, expected *%s", c, t), http.StatusInternalServerError)
+			return
+		}
+		closers, err := inject(c, &o, r)
 		if err != nil {
-			c.Error(http.StatusInternalServerError, err.Error())
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if o.abort || o.timeout > 0 {
+			abortOnCancel(c, r.Context())
+		}
+		v := reflect.ValueOf(c)
+		err = c.Init(w, r)
+		defer func() {
+			var p interface{}
+			if _, ok := c.(Finisher); ok {
+				p = recover()
+			}
+			finish(c, err, p, put, closers)
+		}()
+		if err != nil {
+			fail(c, w, err)
+			return
+		}
+		if limit > 0 && r.ContentLength > limit {
+			err = &HTTPError{Code: http.StatusRequestEntityTooLarge}
+			fail(c, w, err)
+			return
+		}
+		if err = authenticate(c); err != nil {
+			fail(c, w, err)
+			return
+		}
+		if err = authorize(c, o.name); err != nil {
+			fail(c, w, err)
+			return
+		}
+		if err = runFilters(filters, c, w, r); err != nil {
+			fail(c, w, err)
 			return
 		}
-		ret := val.Call([]reflect.Value{v})[0].Interface()
-		if ret != nil {
-			c.Error(http.StatusInternalServerError, ret.(error).Error())
+		var p reflect.Value
+		if param != nil {
+			p = reflect.New(param)
+			if err = Bind(r, p.Interface()); err != nil {
+				fail(c, w, err)
+				return
+			}
+		}
+		err = call(v, p)
+		if o.timeout > 0 {
+			err = timedOut(r, w, err)
+		}
+		if err != nil {
+			fail(c, w, err)
 			return
 		}
-	})
+	}), zero, &o)
+}
+
+// CheckAction returns the error Action panics with for action, or nil if
+// action is a valid action. It lets code registering the methods of a
+// controller by name skip the methods that are not actions.
+func CheckAction(action interface{}) error {
+	_, err := controllerType(reflect.ValueOf(action))
+	return err
 }
 
 func controllerType(action reflect.Value) (reflect.Type, error) {
@@ -129,10 +505,14 @@ func controllerType(action reflect.Value) (reflect.Type, error) {
 		return t, errors.New("Action is not a function")
 	}
 
-	if t.NumIn() != 1 {
+	if t.NumIn() != 1 && t.NumIn() != 2 {
 		return t, errors.New("Wrong Number of Arguments in action")
 	}
 
+	if t.NumIn() == 2 && (t.In(1).Kind() != reflect.Ptr || t.In(1).Elem().Kind() != reflect.Struct) {
+		return t, errors.New("Action argument is not a pointer to a struct")
+	}
+
 	if t.NumOut() != 1 {
 		return t, errors.New("Wrong Number of return values in action")
 	}
@@ -154,6 +534,24 @@ func controllerType(action reflect.Value) (reflect.Type, error) {
 	return t, nil
 }
 
+// paramType returns the struct type bound for the argument of an action, or
+// nil if the action takes no argument.
+func paramType(action reflect.Type) reflect.Type {
+	if action.NumIn() < 2 {
+		return nil
+	}
+	return action.In(1).Elem()
+}
+
+// errorCode returns the HTTP status code err should be reported with.
+func errorCode(err error) int {
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.Code
+	}
+	return http.StatusInternalServerError
+}
+
 func interfaceOf(value interface{}) reflect.Type {
 	t := reflect.TypeOf(value)
 
@@ -1,7 +1,13 @@
 package controller
 
 import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"reflect"
+	"strings"
+	"sync"
 	"testing"
 )
 
@@ -13,9 +19,21 @@ func (t *TestController) Index() error {
 	return nil
 }
 
+func (t *TestController) Create(in *TestParams) error {
+	return nil
+}
+
 func (t *TestController) BadAction() {
 }
 
+func (t *TestController) BadAction3(in TestParams) error {
+	return nil
+}
+
+type TestParams struct {
+	Name string `json:"name" query:"name" validate:"required"`
+}
+
 func (t *TestController) BadAction2() string {
 	return ""
 }
@@ -34,6 +52,8 @@ func TestValidateAction(t *testing.T) {
 		{(*TestController).Index, true},
 		{(*TestController).BadAction, false},
 		{(*TestController).BadAction2, false},
+		{(*TestController).Create, true},
+		{(*TestController).BadAction3, false},
 		{(*NoController).Foo, false},
 		{"bad", false},
 	}
@@ -45,3 +65,541 @@ func TestValidateAction(t *testing.T) {
 		}
 	}
 }
+
+func TestErrorCode(t *testing.T) {
+	var errorTests = []struct {
+		err  error
+		code int
+	}{
+		{errors.New("boom"), http.StatusInternalServerError},
+		{&HTTPError{Code: http.StatusNotFound}, http.StatusNotFound},
+		{fmt.Errorf("wrapped: %w", &HTTPError{Code: http.StatusForbidden}), http.StatusForbidden},
+	}
+
+	for _, test := range errorTests {
+		equals(t, test.code, errorCode(test.err))
+	}
+	equals(t, "Not Found", (&HTTPError{Code: http.StatusNotFound}).Error())
+}
+
+type ParamsController struct {
+	Base
+}
+
+func (c *ParamsController) Create(in *TestParams) error {
+	c.ResponseWriter.Write([]byte("created " + in.Name))
+	return nil
+}
+
+func TestActionParams(t *testing.T) {
+	h := Action((*ParamsController).Create)
+
+	var tests = []struct {
+		method      string
+		url         string
+		contentType string
+		body        string
+		code        int
+		response    string
+	}{
+		{"POST", "/", "application/json", `{"name": "gopher"}`, http.StatusOK, "created gopher"},
+		{"POST", "/", "application/x-www-form-urlencoded", `Name=gopher`, http.StatusOK, "created gopher"},
+		{"GET", "/?name=gopher", "", "", http.StatusOK, "created gopher"},
+		{"POST", "/", "application/json", `{}`, http.StatusUnprocessableEntity, ""},
+		{"POST", "/", "application/json", `{`, http.StatusBadRequest, ""},
+		{"POST", "/", "text/plain", `gopher`, http.StatusUnsupportedMediaType, ""},
+	}
+
+	for _, test := range tests {
+		r := httptest.NewRequest(test.method, test.url, strings.NewReader(test.body))
+		if test.contentType != "" {
+			r.Header.Set("Content-Type", test.contentType)
+		}
+		rw := httptest.NewRecorder()
+		h.ServeHTTP(rw, r)
+		equals(t, test.code, rw.Code)
+		if test.response != "" {
+			equals(t, test.response, rw.Body.String())
+		}
+	}
+}
+
+func TestActionMaxBodyBytes(t *testing.T) {
+	var tests = []struct {
+		opts []Option
+		body string
+		code int
+	}{
+		{nil, `{"name": "gopher"}`, http.StatusOK},
+		{[]Option{MaxBodyBytes(8)}, `{"name": "gopher"}`, http.StatusRequestEntityTooLarge},
+		{[]Option{MaxBodyBytes(0)}, `{"name": "` + strings.Repeat("a", int(DefaultMaxBodyBytes)) + `"}`, http.StatusOK},
+		{nil, `{"name": "` + strings.Repeat("a", int(DefaultMaxBodyBytes)) + `"}`, http.StatusRequestEntityTooLarge},
+	}
+
+	for _, test := range tests {
+		r := httptest.NewRequest("POST", "/", strings.NewReader(test.body))
+		r.Header.Set("Content-Type", "application/json")
+		rw := httptest.NewRecorder()
+		Action((*ParamsController).Create, test.opts...).ServeHTTP(rw, r)
+		equals(t, test.code, rw.Code)
+	}
+
+	// Bodies of unknown length are cut off while binding.
+	r := httptest.NewRequest("POST", "/", strings.NewReader(`{"name": "gopher"}`))
+	r.Header.Set("Content-Type", "application/json")
+	r.ContentLength = -1
+	rw := httptest.NewRecorder()
+	Action((*ParamsController).Create, MaxBodyBytes(8)).ServeHTTP(rw, r)
+	equals(t, http.StatusRequestEntityTooLarge, rw.Code)
+}
+
+func TestActionStrictJSON(t *testing.T) {
+	body := `{"name": "gopher", "nmae": "typo"}`
+
+	var tests = []struct {
+		opts   []Option
+		strict bool
+		code   int
+	}{
+		{nil, false, http.StatusOK},
+		{nil, true, http.StatusUnprocessableEntity},
+		{[]Option{StrictJSON(true)}, false, http.StatusUnprocessableEntity},
+		{[]Option{StrictJSON(false)}, true, http.StatusOK},
+	}
+
+	defer func(strict bool) { DefaultStrictJSON = strict }(DefaultStrictJSON)
+	for _, test := range tests {
+		DefaultStrictJSON = test.strict
+		r := httptest.NewRequest("POST", "/", strings.NewReader(body))
+		r.Header.Set("Content-Type", "application/json")
+		rw := httptest.NewRecorder()
+		Action((*ParamsController).Create, test.opts...).ServeHTTP(rw, r)
+		equals(t, test.code, rw.Code)
+	}
+}
+
+type teapotError struct{}
+
+func (*teapotError) Error() string { return "I'm a teapot" }
+
+type DispatchController struct {
+	Base
+}
+
+func (c *DispatchController) Show() error {
+	return c.Text(http.StatusOK, "show "+c.Request.URL.Query().Get("id"))
+}
+
+func (c *DispatchController) Create(in *TestParams) error {
+	return c.Text(http.StatusCreated, "created "+in.Name)
+}
+
+func (c *DispatchController) Fail() error {
+	return &HTTPError{Code: http.StatusConflict}
+}
+
+func (c *DispatchController) Teapot() *teapotError {
+	if c.Request.URL.Query().Get("ok") != "" {
+		return nil
+	}
+	return &teapotError{}
+}
+
+func (c DispatchController) Value() error {
+	c.ResponseWriter.Write([]byte("value"))
+	return nil
+}
+
+func TestActionDispatch(t *testing.T) {
+	var tests = []struct {
+		action   interface{}
+		url      string
+		code     int
+		response string
+	}{
+		{(*DispatchController).Show, "/?id=1", http.StatusOK, "show 1"},
+		{(*DispatchController).Create, "/?name=gopher", http.StatusCreated, "created gopher"},
+		{(*DispatchController).Fail, "/", http.StatusConflict, "Conflict\n"},
+		{(*DispatchController).Teapot, "/", http.StatusInternalServerError, "I'm a teapot\n"},
+		{(*DispatchController).Teapot, "/?ok=1", http.StatusOK, ""},
+		{DispatchController.Value, "/", http.StatusOK, "value"},
+	}
+
+	for _, test := range tests {
+		rw := httptest.NewRecorder()
+		Action(test.action).ServeHTTP(rw, httptest.NewRequest("GET", test.url, nil))
+		equals(t, test.code, rw.Code)
+		equals(t, test.response, rw.Body.String())
+	}
+}
+
+func BenchmarkAction(b *testing.B) {
+	h := Action((*DispatchController).Show)
+	r := httptest.NewRequest("GET", "/?id=1", nil)
+	rw := httptest.NewRecorder()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		rw.Body.Reset()
+		h.ServeHTTP(rw, r)
+	}
+}
+
+func BenchmarkActionParams(b *testing.B) {
+	h := Action((*DispatchController).Create)
+	r := httptest.NewRequest("GET", "/?name=gopher", nil)
+	rw := httptest.NewRecorder()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		rw.Body.Reset()
+		h.ServeHTTP(rw, r)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	h := Handler(
+		func() Controller { return new(DispatchController) },
+		func(c Controller, r *http.Request) error {
+			in := new(TestParams)
+			if err := Bind(r, in); err != nil {
+				return err
+			}
+			return c.(*DispatchController).Create(in)
+		},
+		MaxBodyBytes(32),
+	)
+
+	var tests = []struct {
+		body     string
+		code     int
+		response string
+	}{
+		{`{"name": "gopher"}`, http.StatusCreated, "created gopher"},
+		{`{}`, http.StatusUnprocessableEntity, ""},
+		{`{"name": "` + strings.Repeat("a", 32) + `"}`, http.StatusRequestEntityTooLarge, ""},
+	}
+
+	for _, test := range tests {
+		r := httptest.NewRequest("POST", "/", strings.NewReader(test.body))
+		r.Header.Set("Content-Type", "application/json")
+		rw := httptest.NewRecorder()
+		h.ServeHTTP(rw, r)
+		equals(t, test.code, rw.Code)
+		if test.response != "" {
+			equals(t, test.response, rw.Body.String())
+		}
+	}
+}
+
+func TestActionOf(t *testing.T) {
+	rw := httptest.NewRecorder()
+	ActionOf((*DispatchController).Show).ServeHTTP(rw, httptest.NewRequest("GET", "/?id=1", nil))
+	equals(t, http.StatusOK, rw.Code)
+	equals(t, "show 1", rw.Body.String())
+
+	rw = httptest.NewRecorder()
+	ActionOf((*DispatchController).Fail).ServeHTTP(rw, httptest.NewRequest("GET", "/", nil))
+	equals(t, http.StatusConflict, rw.Code)
+
+	rw = httptest.NewRecorder()
+	ActionWith((*DispatchController).Create).ServeHTTP(rw, httptest.NewRequest("GET", "/?name=gopher", nil))
+	equals(t, http.StatusCreated, rw.Code)
+	equals(t, "created gopher", rw.Body.String())
+
+	rw = httptest.NewRecorder()
+	ActionWith((*DispatchController).Create).ServeHTTP(rw, httptest.NewRequest("GET", "/", nil))
+	equals(t, http.StatusUnprocessableEntity, rw.Code)
+}
+
+var resets int
+
+type PooledController struct {
+	Base
+	hits int
+}
+
+func (c *PooledController) Reset() {
+	resets++
+	*c = PooledController{}
+}
+
+func (c *PooledController) Index() error {
+	c.hits++
+	return c.Textf(http.StatusOK, "%d", c.hits)
+}
+
+func TestPoolControllers(t *testing.T) {
+	resets = 0
+	for _, h := range []http.Handler{
+		Action((*PooledController).Index, PoolControllers()),
+		ActionOf((*PooledController).Index, PoolControllers()),
+	} {
+		for i := 0; i < 3; i++ {
+			rw := httptest.NewRecorder()
+			h.ServeHTTP(rw, httptest.NewRequest("GET", "/", nil))
+			equals(t, "1", rw.Body.String())
+		}
+	}
+	equals(t, 6, resets)
+
+	// Controllers without a Reset method are allocated for every request.
+	rw := httptest.NewRecorder()
+	Action((*DispatchController).Show, PoolControllers()).ServeHTTP(rw, httptest.NewRequest("GET", "/?id=1", nil))
+	equals(t, "show 1", rw.Body.String())
+}
+
+func BenchmarkActionPooled(b *testing.B) {
+	h := Action((*PooledController).Index, PoolControllers())
+	r := httptest.NewRequest("GET", "/", nil)
+	rw := httptest.NewRecorder()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		rw.Body.Reset()
+		h.ServeHTTP(rw, r)
+	}
+}
+
+func TestActionAllocs(t *testing.T) {
+	var tests = []struct {
+		handler http.Handler
+		url     string
+		allocs  float64
+	}{
+		// The controller is all that is allocated for a request by ActionOf.
+		// Action adds the results of reflect.Call.
+		{Action((*TestController).Index), "/", 3},
+		{ActionOf((*TestController).Index), "/", 1},
+		// Options carried to the binders cost a copy of the request and its
+		// context.
+		{Action((*TestController).Index, StrictJSON(true), MaxBodyBytes(1<<20)), "/", 5},
+	}
+
+	for _, test := range tests {
+		r := httptest.NewRequest("GET", test.url, nil)
+		rw := httptest.NewRecorder()
+		allocs := testing.AllocsPerRun(100, func() {
+			test.handler.ServeHTTP(rw, r)
+		})
+		equals(t, test.allocs, allocs)
+	}
+}
+
+func BenchmarkActionNoop(b *testing.B) {
+	h := Action((*TestController).Index)
+	r := httptest.NewRequest("GET", "/", nil)
+	rw := httptest.NewRecorder()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		h.ServeHTTP(rw, r)
+	}
+}
+
+func BenchmarkActionOf(b *testing.B) {
+	h := ActionOf((*TestController).Index)
+	r := httptest.NewRequest("GET", "/", nil)
+	rw := httptest.NewRecorder()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		h.ServeHTTP(rw, r)
+	}
+}
+
+type InitController struct {
+	Base
+}
+
+func (c *InitController) Init(rw http.ResponseWriter, r *http.Request) error {
+	c.Base.Init(rw, r)
+	if r.URL.Query().Get("fail") != "" {
+		return &HTTPError{Code: http.StatusForbidden}
+	}
+	return nil
+}
+
+func (c *InitController) Index() error {
+	return c.Text(http.StatusOK, "ok")
+}
+
+// TestActionConcurrent serves requests with failing and succeeding Init
+// concurrently through the same handler. Run with -race, it also verifies
+// that no state is shared between requests.
+func TestActionConcurrent(t *testing.T) {
+	h := Action((*InitController).Index)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(fail bool) {
+			defer wg.Done()
+			url, code := "/", http.StatusOK
+			if fail {
+				url, code = "/?fail=1", http.StatusForbidden
+			}
+			for j := 0; j < 20; j++ {
+				rw := httptest.NewRecorder()
+				h.ServeHTTP(rw, httptest.NewRequest("GET", url, nil))
+				if rw.Code != code {
+					t.Errorf("%s: expected status %d, got %d", url, code, rw.Code)
+					return
+				}
+			}
+		}(i%2 == 0)
+	}
+	wg.Wait()
+}
+
+type EmbedsDispatchController struct {
+	Name string
+	DispatchController
+}
+
+// ValueInitController declares Init on a value receiver, which hides the
+// Init of the embedded Base.
+type ValueInitController struct {
+	Base
+	code *int
+}
+
+func (c ValueInitController) Init(rw http.ResponseWriter, r *http.Request) error {
+	return &HTTPError{Code: http.StatusUnauthorized}
+}
+
+func (c *ValueInitController) Error(code int, error string) {
+	*c.code = code
+}
+
+func (c *ValueInitController) Index() error {
+	return c.Text(http.StatusOK, "ok")
+}
+
+func TestInheritedInit(t *testing.T) {
+	// Controllers get the request through an Init promoted from Base.
+	rw := httptest.NewRecorder()
+	Action((*EmbedsDispatchController).Show).ServeHTTP(rw, httptest.NewRequest("GET", "/?id=2", nil))
+	equals(t, "show 2", rw.Body.String())
+
+	// Init declared on a value receiver is called.
+	var code int
+	factory := Factory(func() Controller { return &ValueInitController{code: &code} })
+	Action((*ValueInitController).Index, factory).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	equals(t, http.StatusUnauthorized, code)
+}
+
+type FactoryController struct {
+	Base
+	Greeting string
+}
+
+func (c *FactoryController) Index() error {
+	return c.Text(http.StatusOK, c.Greeting)
+}
+
+func TestFactory(t *testing.T) {
+	factory := Factory(func() Controller {
+		return &FactoryController{Greeting: "hello"}
+	})
+	for _, h := range []http.Handler{
+		Action((*FactoryController).Index, factory),
+		ActionOf((*FactoryController).Index, factory),
+	} {
+		rw := httptest.NewRecorder()
+		h.ServeHTTP(rw, httptest.NewRequest("GET", "/", nil))
+		equals(t, http.StatusOK, rw.Code)
+		equals(t, "hello", rw.Body.String())
+	}
+
+	rw := httptest.NewRecorder()
+	Action((*FactoryController).Index, Factory(func() Controller {
+		return &TestController{}
+	})).ServeHTTP(rw, httptest.NewRequest("GET", "/", nil))
+	equals(t, http.StatusInternalServerError, rw.Code)
+}
+
+type FinishController struct {
+	Base
+	finished *error
+}
+
+func (c *FinishController) Finish(err error) {
+	*c.finished = err
+}
+
+func (c *FinishController) Index() error {
+	return nil
+}
+
+func (c *FinishController) Fail() error {
+	return &HTTPError{Code: http.StatusConflict}
+}
+
+func (c *FinishController) Panic() error {
+	panic("boom")
+}
+
+func TestFinisher(t *testing.T) {
+	var finished error
+	factory := Factory(func() Controller { return &FinishController{finished: &finished} })
+
+	finished = errors.New("Not finished")
+	Action((*FinishController).Index, factory).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	equals(t, nil, finished)
+
+	ActionOf((*FinishController).Fail, factory).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	equals(t, http.StatusConflict, errorCode(finished))
+
+	func() {
+		defer func() {
+			equals(t, "boom", recover())
+		}()
+		Action((*FinishController).Panic, factory).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	}()
+	var panicErr *PanicError
+	assert(t, errors.As(finished, &panicErr), "expected a *PanicError, got %v", finished)
+	equals(t, "boom", panicErr.Value)
+}
+
+type ConstructedController struct {
+	Base
+	greeter  greeter
+	pageSize int
+}
+
+func (*ConstructedController) New() Controller {
+	return &ConstructedController{greeter: englishGreeter{}, pageSize: 20}
+}
+
+func (c *ConstructedController) Index() error {
+	return c.Textf(http.StatusOK, "%s %d", c.greeter.Greet("world"), c.pageSize)
+}
+
+type MisconstructedController struct {
+	Base
+}
+
+func (*MisconstructedController) New() Controller {
+	return &TestController{}
+}
+
+func (c *MisconstructedController) Index() error {
+	return nil
+}
+
+func TestConstructor(t *testing.T) {
+	for _, h := range []http.Handler{
+		Action((*ConstructedController).Index),
+		ActionOf((*ConstructedController).Index),
+	} {
+		rw := httptest.NewRecorder()
+		h.ServeHTTP(rw, httptest.NewRequest("GET", "/", nil))
+		equals(t, http.StatusOK, rw.Code)
+		equals(t, "hello world 20", rw.Body.String())
+	}
+
+	rw := httptest.NewRecorder()
+	Action((*MisconstructedController).Index).ServeHTTP(rw, httptest.NewRequest("GET", "/", nil))
+	equals(t, http.StatusInternalServerError, rw.Code)
+}
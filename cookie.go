@@ -0,0 +1,177 @@
+package controller
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// CookieKeys are the keys signed and encrypted cookies are protected with,
+// registered with Provide or provided to an App:
+//
+//	controller.Provide(controller.CookieKeys{newKey, oldKey})
+//
+// The first key protects the cookies set, and all keys are tried when
+// cookies are read, so keys can be rotated by adding a new key in front and
+// removing the old one once the cookies protected with it have expired.
+// Keys should be at least 32 random bytes.
+type CookieKeys [][]byte
+
+var (
+	// ErrNoCookieKeys is returned by the cookie methods of Base if no
+	// CookieKeys are available.
+	ErrNoCookieKeys = errors.New("No cookie keys provided")
+	// ErrInvalidCookie is returned for cookies that have been tampered with
+	// or were protected with a key that is no longer in use.
+	ErrInvalidCookie = errors.New("Invalid cookie")
+)
+
+// SetSignedCookie sets cookie, signing its value so that changes to it by
+// the client are detected by SignedCookie. The value is readable by the
+// client.
+func (b *Base) SetSignedCookie(cookie *http.Cookie) error {
+	keys, err := b.cookieKeys()
+	if err != nil {
+		return err
+	}
+	value := base64.RawURLEncoding.EncodeToString([]byte(cookie.Value))
+	mac := signCookie(keys[0], cookie.Name, value)
+	return b.setCookie(cookie, value+"."+base64.RawURLEncoding.EncodeToString(mac))
+}
+
+// SignedCookie returns the value of the cookie set with SetSignedCookie. It
+// returns http.ErrNoCookie if the cookie is not set, and ErrInvalidCookie if
+// its signature is invalid.
+func (b *Base) SignedCookie(name string) (string, error) {
+	keys, err := b.cookieKeys()
+	if err != nil {
+		return "", err
+	}
+	c, err := b.Request.Cookie(name)
+	if err != nil {
+		return "", err
+	}
+	value, sig, ok := strings.Cut(c.Value, ".")
+	if !ok {
+		return "", ErrInvalidCookie
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil {
+		return "", ErrInvalidCookie
+	}
+	for _, key := range keys {
+		if hmac.Equal(mac, signCookie(key, name, value)) {
+			data, err := base64.RawURLEncoding.DecodeString(value)
+			if err != nil {
+				return "", ErrInvalidCookie
+			}
+			return string(data), nil
+		}
+	}
+	return "", ErrInvalidCookie
+}
+
+// SetEncryptedCookie sets cookie, encrypting and authenticating its value so
+// that the client can neither read nor change it.
+func (b *Base) SetEncryptedCookie(cookie *http.Cookie) error {
+	keys, err := b.cookieKeys()
+	if err != nil {
+		return err
+	}
+	aead, err := cookieCipher(keys[0])
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(cookie.Value)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	data := aead.Seal(nonce, nonce, []byte(cookie.Value), []byte(cookie.Name))
+	return b.setCookie(cookie, base64.RawURLEncoding.EncodeToString(data))
+}
+
+// EncryptedCookie returns the value of the cookie set with
+// SetEncryptedCookie. It returns http.ErrNoCookie if the cookie is not set,
+// and ErrInvalidCookie if it can not be decrypted.
+func (b *Base) EncryptedCookie(name string) (string, error) {
+	keys, err := b.cookieKeys()
+	if err != nil {
+		return "", err
+	}
+	c, err := b.Request.Cookie(name)
+	if err != nil {
+		return "", err
+	}
+	data, err := base64.RawURLEncoding.DecodeString(c.Value)
+	if err != nil {
+		return "", ErrInvalidCookie
+	}
+	for _, key := range keys {
+		aead, err := cookieCipher(key)
+		if err != nil {
+			return "", err
+		}
+		if len(data) < aead.NonceSize() {
+			return "", ErrInvalidCookie
+		}
+		nonce, ciphertext := data[:aead.NonceSize()], data[aead.NonceSize():]
+		if value, err := aead.Open(nil, nonce, ciphertext, []byte(name)); err == nil {
+			return string(value), nil
+		}
+	}
+	return "", ErrInvalidCookie
+}
+
+// cookieKeys returns the CookieKeys available to the controller.
+func (b *Base) cookieKeys() (CookieKeys, error) {
+	keys, ok := Get[CookieKeys](b)
+	if !ok || len(keys) == 0 {
+		return nil, ErrNoCookieKeys
+	}
+	return keys, nil
+}
+
+// setCookie sets a copy of cookie with value.
+func (b *Base) setCookie(cookie *http.Cookie, value string) error {
+	c := *cookie
+	c.Value = value
+	if err := c.Valid(); err != nil {
+		return err
+	}
+	http.SetCookie(b.ResponseWriter, &c)
+	return nil
+}
+
+// deriveKey derives the key for purpose from key, so that the same key can
+// be used to sign and encrypt cookies.
+func deriveKey(key []byte, purpose string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(purpose))
+	return mac.Sum(nil)
+}
+
+// signCookie returns the signature of the encoded value of the cookie name.
+// The name is signed as well, so that values can not be moved to another
+// cookie.
+func signCookie(key []byte, name, value string) []byte {
+	mac := hmac.New(sha256.New, deriveKey(key, "signed cookie"))
+	mac.Write([]byte(name))
+	mac.Write([]byte{0})
+	mac.Write([]byte(value))
+	return mac.Sum(nil)
+}
+
+// cookieCipher returns the cipher encrypting cookies with key.
+func cookieCipher(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(deriveKey(key, "encrypted cookie"))
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
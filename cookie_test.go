@@ -0,0 +1,80 @@
+package controller
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type CookieController struct {
+	Base
+}
+
+func (c *CookieController) Set() error {
+	if err := c.SetSignedCookie(&http.Cookie{Name: "theme", Value: "dark mode"}); err != nil {
+		return err
+	}
+	if err := c.SetEncryptedCookie(&http.Cookie{Name: "cart", Value: "42,43"}); err != nil {
+		return err
+	}
+	return c.NoContent()
+}
+
+func (c *CookieController) Show() error {
+	theme, err := c.SignedCookie("theme")
+	if err != nil {
+		return &HTTPError{Code: http.StatusBadRequest, Err: err}
+	}
+	cart, err := c.EncryptedCookie("cart")
+	if err != nil {
+		return &HTTPError{Code: http.StatusBadRequest, Err: err}
+	}
+	return c.Text(http.StatusOK, theme+" "+cart)
+}
+
+func TestCookies(t *testing.T) {
+	oldKey, newKey := []byte("old key of at least thirty-two bytes"), []byte("new key of at least thirty-two bytes")
+	serve := func(keys CookieKeys, action interface{}, cookies []*http.Cookie) *httptest.ResponseRecorder {
+		app := &App{}
+		if keys != nil {
+			app.Provide(keys)
+		}
+		r := httptest.NewRequest("GET", "/", nil)
+		for _, c := range cookies {
+			r.AddCookie(c)
+		}
+		rw := httptest.NewRecorder()
+		app.Action(action).ServeHTTP(rw, r)
+		return rw
+	}
+
+	rw := serve(CookieKeys{oldKey}, (*CookieController).Set, nil)
+	equals(t, http.StatusNoContent, rw.Code)
+	cookies := rw.Result().Cookies()
+	equals(t, 2, len(cookies))
+	assert(t, !strings.Contains(cookies[1].Value, "42"), "expected the value of the encrypted cookie to be hidden\n")
+
+	// Cookies protected with an old key are still accepted.
+	rw = serve(CookieKeys{newKey, oldKey}, (*CookieController).Show, cookies)
+	equals(t, "dark mode 42,43", rw.Body.String())
+
+	rw = serve(CookieKeys{newKey}, (*CookieController).Show, cookies)
+	equals(t, http.StatusBadRequest, rw.Code)
+	equals(t, "Invalid cookie\n", rw.Body.String())
+
+	// Values can not be changed or moved to another cookie.
+	tampered := []*http.Cookie{{Name: "theme", Value: "bGlnaHQ." + strings.SplitN(cookies[0].Value, ".", 2)[1]}, cookies[1]}
+	rw = serve(CookieKeys{oldKey}, (*CookieController).Show, tampered)
+	equals(t, "Invalid cookie\n", rw.Body.String())
+	moved := []*http.Cookie{cookies[0], {Name: "cart", Value: cookies[0].Value}}
+	rw = serve(CookieKeys{oldKey}, (*CookieController).Show, moved)
+	equals(t, "Invalid cookie\n", rw.Body.String())
+
+	rw = serve(CookieKeys{oldKey}, (*CookieController).Show, nil)
+	equals(t, "http: named cookie not present\n", rw.Body.String())
+
+	rw = serve(nil, (*CookieController).Set, nil)
+	equals(t, http.StatusInternalServerError, rw.Code)
+	equals(t, "No cookie keys provided\n", rw.Body.String())
+}
@@ -0,0 +1,134 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CORS configures Cross-Origin Resource Sharing for API controllers. Its
+// Handler method is a middleware, attached to a controller with
+// MiddlewareProvider, to single actions with Use, or to a group of routes by
+// wrapping their mux:
+//
+//	var apiCORS = &controller.CORS{
+//		AllowedOrigins:   []string{"https://app.example.com"},
+//		AllowCredentials: true,
+//	}
+//
+//	func (*APIController) Middleware() []func(http.Handler) http.Handler {
+//		return []func(http.Handler) http.Handler{apiCORS.Handler}
+//	}
+//
+// Preflight requests are answered by the middleware without reaching the
+// controller. Mount passes preflight requests to its automatic OPTIONS
+// handlers through the middleware of the controller routing the requested
+// method, so routes mounted with it need no OPTIONS actions.
+type CORS struct {
+	// AllowedOrigins lists the origins allowed to make requests, such as
+	// "https://app.example.com". "*" allows every origin, but not in
+	// combination with AllowCredentials.
+	AllowedOrigins []string
+	// AllowedMethods lists the methods allowed for cross-origin requests. It
+	// defaults to GET, HEAD, POST, PUT, PATCH and DELETE.
+	AllowedMethods []string
+	// AllowedHeaders lists the request headers allowed for cross-origin
+	// requests. If it is nil, the headers requested by preflight requests
+	// are allowed.
+	AllowedHeaders []string
+	// ExposedHeaders lists the response headers scripts may read besides the
+	// CORS-safelisted ones.
+	ExposedHeaders []string
+	// AllowCredentials allows requests with cookies or HTTP authentication.
+	AllowCredentials bool
+	// MaxAge is how long browsers may cache the result of a preflight
+	// request. Browsers use their own default if it is zero.
+	MaxAge time.Duration
+}
+
+var defaultCORSMethods = []string{
+	http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete,
+}
+
+// Handler is a middleware applying the configuration to the requests
+// handled by next. It panics if the configuration allows credentials for
+// any origin with "*", which would let every website make authenticated
+// requests on behalf of the users of the application.
+func (c *CORS) Handler(next http.Handler) http.Handler {
+	wildcard := contains(c.AllowedOrigins, "*")
+	if wildcard && c.AllowCredentials {
+		panic(`controller: CORS can not allow credentials for any origin with "*"`)
+	}
+	methods := c.AllowedMethods
+	if methods == nil {
+		methods = defaultCORSMethods
+	}
+	allowMethods := strings.Join(methods, ", ")
+	allowHeaders := strings.Join(c.AllowedHeaders, ", ")
+	exposeHeaders := strings.Join(c.ExposedHeaders, ", ")
+
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			next.ServeHTTP(rw, r)
+			return
+		}
+		h := rw.Header()
+		h.Add("Vary", "Origin")
+		allowed := c.allowOrigin(origin)
+
+		if method := r.Header.Get("Access-Control-Request-Method"); r.Method == http.MethodOptions && method != "" {
+			h.Add("Vary", "Access-Control-Request-Method")
+			h.Add("Vary", "Access-Control-Request-Headers")
+			if allowed && contains(methods, method) {
+				c.allowHeaders(h, origin, wildcard)
+				h.Set("Access-Control-Allow-Methods", allowMethods)
+				if c.AllowedHeaders == nil {
+					if requested := r.Header.Get("Access-Control-Request-Headers"); requested != "" {
+						h.Set("Access-Control-Allow-Headers", requested)
+					}
+				} else if allowHeaders != "" {
+					h.Set("Access-Control-Allow-Headers", allowHeaders)
+				}
+				if c.MaxAge > 0 {
+					h.Set("Access-Control-Max-Age", strconv.Itoa(int(c.MaxAge/time.Second)))
+				}
+			}
+			rw.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		if allowed {
+			c.allowHeaders(h, origin, wildcard)
+			if exposeHeaders != "" {
+				h.Set("Access-Control-Expose-Headers", exposeHeaders)
+			}
+		}
+		next.ServeHTTP(rw, r)
+	})
+}
+
+// allowOrigin reports whether requests from origin are allowed.
+func (c *CORS) allowOrigin(origin string) bool {
+	for _, allowed := range c.AllowedOrigins {
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// allowHeaders sets the headers allowing a request from origin. Requests
+// allowed by a wildcard get a literal "*", which browsers never accept for
+// credentialed requests.
+func (c *CORS) allowHeaders(h http.Header, origin string, wildcard bool) {
+	if wildcard {
+		h.Set("Access-Control-Allow-Origin", "*")
+		return
+	}
+	h.Set("Access-Control-Allow-Origin", origin)
+	if c.AllowCredentials {
+		h.Set("Access-Control-Allow-Credentials", "true")
+	}
+}
@@ -0,0 +1,107 @@
+package controller
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+var testCORS = &CORS{
+	AllowedOrigins:   []string{"https://app.example.com"},
+	ExposedHeaders:   []string{"X-Total-Count"},
+	AllowCredentials: true,
+	MaxAge:           time.Hour,
+}
+
+type CORSController struct {
+	Base
+}
+
+func (*CORSController) Middleware() []func(http.Handler) http.Handler {
+	return []func(http.Handler) http.Handler{testCORS.Handler}
+}
+
+func (c *CORSController) Routes(r Registrar) {
+	r.Handle("GET", "/items", (*CORSController).Index)
+	r.Handle("PUT", "/items", (*CORSController).Update)
+}
+
+func (c *CORSController) Index() error {
+	return c.Text(http.StatusOK, "items")
+}
+
+func (c *CORSController) Update() error {
+	return c.Text(http.StatusOK, "updated")
+}
+
+func TestCORS(t *testing.T) {
+	mux := http.NewServeMux()
+	Mount(mux, "/", (*CORSController)(nil))
+
+	// Preflight requests are answered for allowed origins and methods only.
+	preflight := func(origin, method string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("OPTIONS", "/items", nil)
+		r.Header.Set("Origin", origin)
+		r.Header.Set("Access-Control-Request-Method", method)
+		r.Header.Set("Access-Control-Request-Headers", "Content-Type")
+		rw := httptest.NewRecorder()
+		mux.ServeHTTP(rw, r)
+		return rw
+	}
+	rw := preflight("https://app.example.com", "PUT")
+	equals(t, http.StatusNoContent, rw.Code)
+	equals(t, "https://app.example.com", rw.Header().Get("Access-Control-Allow-Origin"))
+	equals(t, "GET, HEAD, POST, PUT, PATCH, DELETE", rw.Header().Get("Access-Control-Allow-Methods"))
+	equals(t, "Content-Type", rw.Header().Get("Access-Control-Allow-Headers"))
+	equals(t, "true", rw.Header().Get("Access-Control-Allow-Credentials"))
+	equals(t, "3600", rw.Header().Get("Access-Control-Max-Age"))
+
+	for _, rw := range []*httptest.ResponseRecorder{
+		preflight("https://evil.example.com", "PUT"),
+		preflight("https://app.example.com", "CONNECT"),
+	} {
+		equals(t, http.StatusNoContent, rw.Code)
+		equals(t, "", rw.Header().Get("Access-Control-Allow-Origin"))
+	}
+
+	// Actual requests reach the action.
+	r := httptest.NewRequest("GET", "/items", nil)
+	r.Header.Set("Origin", "https://app.example.com")
+	rw = httptest.NewRecorder()
+	mux.ServeHTTP(rw, r)
+	equals(t, "items", rw.Body.String())
+	equals(t, "https://app.example.com", rw.Header().Get("Access-Control-Allow-Origin"))
+	equals(t, "X-Total-Count", rw.Header().Get("Access-Control-Expose-Headers"))
+	equals(t, "Origin", rw.Header().Get("Vary"))
+
+	rw = httptest.NewRecorder()
+	mux.ServeHTTP(rw, httptest.NewRequest("GET", "/items", nil))
+	equals(t, "items", rw.Body.String())
+	equals(t, "", rw.Header().Get("Access-Control-Allow-Origin"))
+
+	// Without credentials, any origin is allowed with a wildcard.
+	h := (&CORS{AllowedOrigins: []string{"*"}}).Handler(Action((*CORSController).Index))
+	r = httptest.NewRequest("GET", "/items", nil)
+	r.Header.Set("Origin", "https://other.example.com")
+	rw = httptest.NewRecorder()
+	h.ServeHTTP(rw, r)
+	equals(t, "*", rw.Header().Get("Access-Control-Allow-Origin"))
+}
+
+func TestCORSCredentials(t *testing.T) {
+	// Origins that are not listed get no credentialed allow.
+	h := testCORS.Handler(Action((*CORSController).Index))
+	r := httptest.NewRequest("GET", "/items", nil)
+	r.Header.Set("Origin", "https://evil.example")
+	rw := httptest.NewRecorder()
+	h.ServeHTTP(rw, r)
+	equals(t, "", rw.Header().Get("Access-Control-Allow-Origin"))
+	equals(t, "", rw.Header().Get("Access-Control-Allow-Credentials"))
+
+	// Credentials can not be allowed for any origin.
+	defer func() {
+		assert(t, recover() != nil, "expected credentials with a wildcard origin to panic\n")
+	}()
+	(&CORS{AllowedOrigins: []string{"*"}, AllowCredentials: true}).Handler(Action((*CORSController).Index))
+}
@@ -0,0 +1,213 @@
+// Package csrf protects controllers against cross-site request forgery.
+//
+// Protect returns a controller.Filter that gives every client a secret
+// token, stored in a cookie by default, and rejects POST, PUT, PATCH and
+// DELETE requests that do not send it back with 403 Forbidden, through the
+// Error method of the controller. Controllers opt in per controller or per
+// action:
+//
+//	func (*AccountController) ActionFilters() []controller.Filter {
+//		return []controller.Filter{csrf.Protect(nil)}
+//	}
+//
+// Forms send the token in a field named FieldName, scripts in a header named
+// HeaderName. Token returns the value to send:
+//
+//	<input type="hidden" name="csrf_token" value="{{.CSRFToken}}">
+//
+// The default CookieStore signs its cookie with the controller.CookieKeys
+// available to the controller, so that it can not be planted by a sibling
+// domain.
+package csrf
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"net/http"
+
+	"github.com/codegangsta/controller"
+)
+
+var (
+	// HeaderName is the request header the token is read from.
+	HeaderName = "X-CSRF-Token"
+	// FieldName is the form field the token is read from if the header is
+	// not set.
+	FieldName = "csrf_token"
+)
+
+// tokenLength is the number of random bytes of a token.
+const tokenLength = 32
+
+// tokenKey is the key the token of a request is stored under in the
+// controller.
+const tokenKey = "csrf.token"
+
+// ErrInvalidToken is the error requests without a valid token fail with,
+// wrapped in a controller.HTTPError with code 403.
+var ErrInvalidToken = errors.New("CSRF token missing or invalid")
+
+// ErrNoBase is returned by CookieStore for controllers that do not embed
+// controller.Base.
+var ErrNoBase = errors.New("csrf: CookieStore requires controllers embedding controller.Base")
+
+// Store stores the secret token of a client, for instance in a cookie or in
+// the session of the client.
+type Store interface {
+	// Token returns the token stored for the client of r, or nil if there is
+	// none.
+	Token(c controller.Controller, r *http.Request) ([]byte, error)
+	// Save stores token for the client of r.
+	Save(c controller.Controller, rw http.ResponseWriter, r *http.Request, token []byte) error
+}
+
+// signedCookies is implemented by controllers embedding controller.Base.
+type signedCookies interface {
+	SetSignedCookie(cookie *http.Cookie) error
+	SignedCookie(name string) (string, error)
+}
+
+// CookieStore stores tokens in a cookie, signed with the CookieKeys of the
+// controller. The cookie is HttpOnly, SameSite Lax and Secure.
+type CookieStore struct {
+	// Name is the name of the cookie. It defaults to "_csrf".
+	Name string
+	// Path is the path of the cookie. It defaults to "/".
+	Path string
+	// Domain is the domain of the cookie, if any.
+	Domain string
+	// MaxAge is the lifetime of the cookie in seconds. Cookies last for the
+	// browser session if it is zero.
+	MaxAge int
+	// Insecure allows browsers to send the cookie over plain HTTP, for
+	// development servers without TLS.
+	Insecure bool
+}
+
+func (s *CookieStore) name() string {
+	if s.Name == "" {
+		return "_csrf"
+	}
+	return s.Name
+}
+
+// Token returns the token of the cookie sent with r. Cookies with an
+// invalid signature are ignored, so that the client gets a new token.
+func (s *CookieStore) Token(c controller.Controller, r *http.Request) ([]byte, error) {
+	sc, ok := c.(signedCookies)
+	if !ok {
+		return nil, ErrNoBase
+	}
+	value, err := sc.SignedCookie(s.name())
+	if err == http.ErrNoCookie || err == controller.ErrInvalidCookie {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return base64.RawURLEncoding.DecodeString(value)
+}
+
+// Save sets the cookie to token.
+func (s *CookieStore) Save(c controller.Controller, rw http.ResponseWriter, r *http.Request, token []byte) error {
+	sc, ok := c.(signedCookies)
+	if !ok {
+		return ErrNoBase
+	}
+	path := s.Path
+	if path == "" {
+		path = "/"
+	}
+	return sc.SetSignedCookie(&http.Cookie{
+		Name:     s.name(),
+		Value:    base64.RawURLEncoding.EncodeToString(token),
+		Path:     path,
+		Domain:   s.Domain,
+		MaxAge:   s.MaxAge,
+		Secure:   !s.Insecure,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// Protect returns a filter verifying the tokens of requests with the tokens
+// kept in store, or in a CookieStore with default settings if store is nil.
+// Requests with the safe methods GET, HEAD, OPTIONS and TRACE are not
+// verified, but get a token if they have none. Tokens are only read from
+// forms of controllers embedding controller.Base, whose PostFormValue
+// respects the body limits of the controller.
+func Protect(store Store) controller.Filter {
+	if store == nil {
+		store = &CookieStore{}
+	}
+	return func(c controller.Controller, rw http.ResponseWriter, r *http.Request) error {
+		token, err := store.Token(c, r)
+		if err != nil || len(token) != tokenLength {
+			token = make([]byte, tokenLength)
+			if _, err := rand.Read(token); err != nil {
+				return err
+			}
+			if err := store.Save(c, rw, r, token); err != nil {
+				return err
+			}
+		}
+		if s, ok := c.(interface{ Set(string, interface{}) }); ok {
+			s.Set(tokenKey, mask(token))
+		}
+
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+			return nil
+		}
+		sent := r.Header.Get(HeaderName)
+		if f, ok := c.(interface{ PostFormValue(string) string }); ok && sent == "" {
+			sent = f.PostFormValue(FieldName)
+		}
+		if !valid(sent, token) {
+			return &controller.HTTPError{Code: http.StatusForbidden, Err: ErrInvalidToken}
+		}
+		return nil
+	}
+}
+
+// Token returns the token clients send back to pass the filter returned by
+// Protect, or an empty string if the filter has not run for c. Tokens are
+// masked with a random value that changes with every request, so that
+// responses containing them do not leak the secret through compression.
+func Token(c controller.Controller) string {
+	g, ok := c.(interface {
+		Get(string) (interface{}, bool)
+	})
+	if !ok {
+		return ""
+	}
+	token, _ := g.Get(tokenKey)
+	s, _ := token.(string)
+	return s
+}
+
+// mask returns token XORed with a random pad, prefixed by the pad.
+func mask(token []byte) string {
+	masked := make([]byte, 2*len(token))
+	pad := masked[:len(token)]
+	rand.Read(pad)
+	for i, b := range token {
+		masked[len(token)+i] = b ^ pad[i]
+	}
+	return base64.RawURLEncoding.EncodeToString(masked)
+}
+
+// valid reports whether sent is a masked copy of token.
+func valid(sent string, token []byte) bool {
+	masked, err := base64.RawURLEncoding.DecodeString(sent)
+	if err != nil || len(masked) != 2*len(token) {
+		return false
+	}
+	unmasked := make([]byte, len(token))
+	for i := range unmasked {
+		unmasked[i] = masked[i] ^ masked[len(token)+i]
+	}
+	return subtle.ConstantTimeCompare(unmasked, token) == 1
+}
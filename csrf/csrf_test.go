@@ -0,0 +1,113 @@
+package csrf
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/codegangsta/controller"
+)
+
+type AccountController struct {
+	controller.Base
+}
+
+func (*AccountController) ActionFilters() []controller.Filter {
+	return []controller.Filter{Protect(nil)}
+}
+
+func (c *AccountController) Edit() error {
+	return c.Text(http.StatusOK, Token(c))
+}
+
+func (c *AccountController) Update() error {
+	return c.Text(http.StatusOK, "updated")
+}
+
+func newApp() *controller.App {
+	app := &controller.App{}
+	app.Provide(controller.CookieKeys{[]byte("key of at least thirty-two bytes")})
+	return app
+}
+
+func TestProtect(t *testing.T) {
+	app := newApp()
+	rw := httptest.NewRecorder()
+	app.Action((*AccountController).Edit).ServeHTTP(rw, httptest.NewRequest("GET", "/account", nil))
+	cookies := rw.Result().Cookies()
+	if rw.Code != http.StatusOK || len(cookies) != 1 || rw.Body.String() == "" {
+		t.Fatalf("Expected a token and a cookie, got %d %q %v", rw.Code, rw.Body.String(), cookies)
+	}
+	token, cookie := rw.Body.String(), cookies[0]
+	if !cookie.HttpOnly || !cookie.Secure || cookie.SameSite != http.SameSiteLaxMode {
+		t.Errorf("Expected a Secure HttpOnly SameSite cookie, got %v", cookie)
+	}
+
+	// A cookie planted by a sibling domain is not signed.
+	tossed := &http.Cookie{Name: cookie.Name, Value: strings.SplitN(cookie.Value, ".", 2)[0]}
+
+	h := app.Action((*AccountController).Update)
+	var tests = []struct {
+		name   string
+		header string
+		form   string
+		cookie *http.Cookie
+		code   int
+	}{
+		{"no token", "", "", cookie, http.StatusForbidden},
+		{"no cookie", token, "", nil, http.StatusForbidden},
+		{"forged token", "forged", "", cookie, http.StatusForbidden},
+		{"tossed cookie", token, "", tossed, http.StatusForbidden},
+		{"header", token, "", cookie, http.StatusOK},
+		{"form", "", token, cookie, http.StatusOK},
+	}
+	for _, test := range tests {
+		r := httptest.NewRequest("POST", "/account", strings.NewReader(url.Values{FieldName: {test.form}}.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		if test.header != "" {
+			r.Header.Set(HeaderName, test.header)
+		}
+		if test.cookie != nil {
+			r.AddCookie(test.cookie)
+		}
+		rw := httptest.NewRecorder()
+		h.ServeHTTP(rw, r)
+		if rw.Code != test.code {
+			t.Errorf("%s: expected %d, got %d %q", test.name, test.code, rw.Code, rw.Body.String())
+		}
+	}
+}
+
+func TestMask(t *testing.T) {
+	token := []byte(strings.Repeat("k", tokenLength))
+	a, b := mask(token), mask(token)
+	if a == b {
+		t.Errorf("Expected masked tokens to differ")
+	}
+	if !valid(a, token) || !valid(b, token) {
+		t.Errorf("Expected masked tokens to be valid")
+	}
+}
+
+type DevController struct {
+	controller.Base
+}
+
+func (*DevController) ActionFilters() []controller.Filter {
+	return []controller.Filter{Protect(&CookieStore{Insecure: true})}
+}
+
+func (c *DevController) Edit() error {
+	return c.Text(http.StatusOK, Token(c))
+}
+
+func TestInsecure(t *testing.T) {
+	rw := httptest.NewRecorder()
+	newApp().Action((*DevController).Edit).ServeHTTP(rw, httptest.NewRequest("GET", "/account", nil))
+	cookies := rw.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Secure {
+		t.Errorf("Expected a cookie without Secure, got %v", cookies)
+	}
+}
@@ -0,0 +1,94 @@
+// Package db provides a controller base that runs every action in a database
+// transaction.
+//
+// Controllers embed Controller in place of controller.Base and use Tx for
+// their queries:
+//
+//	type UserController struct {
+//		db.Controller
+//	}
+//
+//	func (c *UserController) Create(in *CreateUserRequest) error {
+//		_, err := c.Tx.ExecContext(c.Context(), "INSERT INTO users (name) VALUES ($1)", in.Name)
+//		return err
+//	}
+//
+//	controller.Provide(sqlDB)
+//	http.Handle("POST /users", controller.Action((*UserController).Create))
+//
+// The transaction is committed when the action succeeds, and rolled back when
+// Init or the action returns an error or panics.
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/codegangsta/controller"
+)
+
+// Controller is a controller.Base that opens a transaction on DB in Init and
+// finishes it once the action has run. DB is injected with the *sql.DB
+// registered with controller.Provide or provided to the App of the action.
+//
+// Controllers that implement Init or Finish themselves must call the methods
+// of Controller.
+type Controller struct {
+	controller.Base
+
+	DB *sql.DB `inject:""`
+	// Tx is the transaction of the request. It is nil once the transaction
+	// has been committed or rolled back.
+	Tx *sql.Tx
+	// TxOptions are the options the transaction is started with. Init uses
+	// the default isolation level of the driver if they are nil.
+	TxOptions *sql.TxOptions
+}
+
+// Init initializes the base controller and begins the transaction. The
+// transaction is bound to the context of the request, so it is rolled back
+// if the client goes away.
+func (c *Controller) Init(rw http.ResponseWriter, r *http.Request) error {
+	if err := c.Base.Init(rw, r); err != nil {
+		return err
+	}
+	if c.DB == nil {
+		return errors.New("No database to begin the transaction on")
+	}
+	tx, err := c.DB.BeginTx(r.Context(), c.TxOptions)
+	if err != nil {
+		return err
+	}
+	c.Tx = tx
+	return nil
+}
+
+// Finish commits the transaction if err is nil, and rolls it back otherwise.
+// If the commit fails, the response of the action is replaced with the error
+// unless it has been sent already.
+func (c *Controller) Finish(err error) {
+	tx := c.Tx
+	if tx == nil {
+		return
+	}
+	c.Tx = nil
+	if err != nil {
+		tx.Rollback()
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		if w, ok := c.ResponseWriter.(*controller.ResponseWriter); ok && w.Discard() {
+			c.Error(http.StatusInternalServerError, err.Error())
+		}
+	}
+}
+
+// Destroy rolls back the transaction if it has not been finished.
+func (c *Controller) Destroy() {
+	if c.Tx != nil {
+		c.Tx.Rollback()
+		c.Tx = nil
+	}
+	c.Base.Destroy()
+}
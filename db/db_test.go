@@ -0,0 +1,139 @@
+package db
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/codegangsta/controller"
+)
+
+// recorder is a database driver that records the transactions it is asked to
+// commit or roll back.
+type recorder struct {
+	mu        sync.Mutex
+	events    []string
+	commitErr error
+}
+
+func (d *recorder) record(event string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.events = append(d.events, event)
+}
+
+func (d *recorder) Open(name string) (driver.Conn, error) { return conn{d}, nil }
+
+type conn struct{ d *recorder }
+
+func (c conn) Prepare(query string) (driver.Stmt, error) { return nil, errors.New("Not supported") }
+func (c conn) Close() error                              { return nil }
+func (c conn) Begin() (driver.Tx, error) {
+	c.d.record("begin")
+	return tx{c.d}, nil
+}
+
+type tx struct{ d *recorder }
+
+func (t tx) Commit() error {
+	t.d.record("commit")
+	return t.d.commitErr
+}
+
+func (t tx) Rollback() error {
+	t.d.record("rollback")
+	return nil
+}
+
+var driverOnce sync.Once
+
+var testDriver = &recorder{}
+
+func openDB(t *testing.T) *sql.DB {
+	driverOnce.Do(func() { sql.Register("recorder", testDriver) })
+	testDriver.events, testDriver.commitErr = nil, nil
+	db, err := sql.Open("recorder", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+type UserController struct {
+	Controller
+}
+
+func (c *UserController) Create() error {
+	if c.Tx == nil {
+		return errors.New("No transaction")
+	}
+	c.ResponseWriter.Write([]byte("created"))
+	return nil
+}
+
+func (c *UserController) Fail() error {
+	return &controller.HTTPError{Code: http.StatusConflict}
+}
+
+func (c *UserController) Panic() error {
+	panic("boom")
+}
+
+func TestController(t *testing.T) {
+	var tests = []struct {
+		action    interface{}
+		commitErr error
+		code      int
+		body      string
+		events    []string
+	}{
+		{(*UserController).Create, nil, http.StatusOK, "created", []string{"begin", "commit"}},
+		{(*UserController).Fail, nil, http.StatusConflict, "Conflict\n", []string{"begin", "rollback"}},
+		{(*UserController).Create, errors.New("Serialization failure"), http.StatusInternalServerError, "Serialization failure\n", []string{"begin", "commit"}},
+	}
+	for _, test := range tests {
+		db := openDB(t)
+		app := &controller.App{}
+		app.Provide(db)
+		testDriver.commitErr = test.commitErr
+
+		rw := httptest.NewRecorder()
+		app.Action(test.action).ServeHTTP(rw, httptest.NewRequest("POST", "/users", nil))
+		if rw.Code != test.code || rw.Body.String() != test.body {
+			t.Errorf("Expected %d %q, got %d %q", test.code, test.body, rw.Code, rw.Body.String())
+		}
+		if !reflect.DeepEqual(testDriver.events, test.events) {
+			t.Errorf("Expected %v, got %v", test.events, testDriver.events)
+		}
+	}
+}
+
+func TestControllerPanic(t *testing.T) {
+	app := &controller.App{}
+	app.Provide(openDB(t))
+
+	defer func() {
+		if p := recover(); p != "boom" {
+			t.Errorf("Expected the panic to continue, got %v", p)
+		}
+		if events := []string{"begin", "rollback"}; !reflect.DeepEqual(testDriver.events, events) {
+			t.Errorf("Expected %v, got %v", events, testDriver.events)
+		}
+	}()
+	app.Action((*UserController).Panic).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/", nil))
+}
+
+func TestControllerNoDB(t *testing.T) {
+	rw := httptest.NewRecorder()
+	controller.Action((*UserController).Create).ServeHTTP(rw, httptest.NewRequest("POST", "/", nil))
+	if rw.Code != http.StatusInternalServerError || !strings.Contains(rw.Body.String(), "*sql.DB") {
+		t.Errorf("Expected 500 for the missing database, got %d %q", rw.Code, rw.Body.String())
+	}
+}
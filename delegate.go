@@ -0,0 +1,46 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+)
+
+// Delegate hands the request over to h, so that actions can serve existing
+// handlers such as pprof, metrics or third-party handlers while keeping the
+// Init, Destroy and Error lifecycle of the controller:
+//
+//	func (c *AdminController) Profile() error {
+//		return c.Delegate(http.HandlerFunc(pprof.Index))
+//	}
+//
+// h writes the response with the ResponseWriter of the controller.
+func (b *Base) Delegate(h http.Handler) error {
+	if b.ResponseWriter == nil || b.Request == nil {
+		return errors.New("Can not delegate without a request")
+	}
+	h.ServeHTTP(b.ResponseWriter, b.Request)
+	return nil
+}
+
+// Delegate returns a handler serving requests with h behind the lifecycle of
+// the controller type T, which embeds Base. T is initialized and destroyed
+// for every request as for an action, which lets Init authenticate requests
+// for handlers that know nothing about it:
+//
+//	mux.Handle("GET /debug/pprof/", controller.Delegate[AdminController](http.HandlerFunc(pprof.Index)))
+func Delegate[T any, PT interface {
+	*T
+	Controller
+}](h http.Handler, opts ...Option) http.Handler {
+	return Handler(
+		NewFunc[T, PT](),
+		func(c Controller, r *http.Request) error {
+			b, ok := c.(baser)
+			if !ok {
+				return errors.New("Can not delegate from a controller without Base")
+			}
+			return b.base().Delegate(h)
+		},
+		opts...,
+	)
+}
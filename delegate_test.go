@@ -0,0 +1,46 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type DelegateController struct {
+	Base
+}
+
+func (c *DelegateController) Init(rw http.ResponseWriter, r *http.Request) error {
+	c.Base.Init(rw, r)
+	if r.Header.Get("Authorization") == "" {
+		return &HTTPError{Code: http.StatusUnauthorized, Err: errors.New("Authorization required")}
+	}
+	return nil
+}
+
+func (c *DelegateController) Metrics() error {
+	return c.Delegate(http.HandlerFunc(metrics))
+}
+
+func metrics(rw http.ResponseWriter, r *http.Request) {
+	rw.Write([]byte("requests 42"))
+}
+
+func TestDelegate(t *testing.T) {
+	for _, h := range []http.Handler{
+		Action((*DelegateController).Metrics),
+		Delegate[DelegateController](http.HandlerFunc(metrics)),
+	} {
+		rw := httptest.NewRecorder()
+		h.ServeHTTP(rw, httptest.NewRequest("GET", "/metrics", nil))
+		equals(t, http.StatusUnauthorized, rw.Code)
+
+		r := httptest.NewRequest("GET", "/metrics", nil)
+		r.Header.Set("Authorization", "Bearer token")
+		rw = httptest.NewRecorder()
+		h.ServeHTTP(rw, r)
+		equals(t, http.StatusOK, rw.Code)
+		equals(t, "requests 42", rw.Body.String())
+	}
+}
@@ -0,0 +1,189 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"sync"
+)
+
+// Handler returns an http.Handler that serves requests like the handlers
+// returned by Action, without using reflection: newController constructs
+// the controller for a request, and action invokes the action on it after
+// Init. Action returns the same errors an action would, and binds arguments
+// with Bind. Handler calls newController once more itself, to look up the
+// middleware and filters declared by the controller.
+//
+// Handler is the building block of the handlers generated by
+// cmd/controllergen, but can also be used directly:
+//
+//	controller.Handler(
+//		func() controller.Controller { return new(UserController) },
+//		func(c controller.Controller, r *http.Request) error {
+//			return c.(*UserController).Index()
+//		},
+//	)
+func Handler(newController func() Controller, action func(c Controller, r *http.Request) error, opts ...Option) http.Handler {
+	o := newOptions(opts)
+	zero := newController()
+	if o.factory != nil {
+		newController = o.factory
+	}
+	get, put := o.recycler(newController)
+	filters := o.filtersFor(zero)
+
+	return withMiddleware(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		r, limit := o.prepare(rw, r)
+		if o.timeout > 0 {
+			var cancel context.CancelFunc
+			r, cancel = o.withTimeout(r)
+			defer cancel()
+		}
+		w := newResponseWriter(rw)
+		defer w.release()
+
+		c := get()
+		closers, err := inject(c, &o, r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if o.abort || o.timeout > 0 {
+			abortOnCancel(c, r.Context())
+		}
+		err = c.Init(w, r)
+		defer func() {
+			var p interface{}
+			if _, ok := c.(Finisher); ok {
+				p = recover()
+			}
+			finish(c, err, p, put, closers)
+		}()
+		if err != nil {
+			fail(c, w, err)
+			return
+		}
+		if limit > 0 && r.ContentLength > limit {
+			err = &HTTPError{Code: http.StatusRequestEntityTooLarge}
+			fail(c, w, err)
+			return
+		}
+		if err = authenticate(c); err != nil {
+			fail(c, w, err)
+			return
+		}
+		if err = authorize(c, o.name); err != nil {
+			fail(c, w, err)
+			return
+		}
+		if err = runFilters(filters, c, w, r); err != nil {
+			fail(c, w, err)
+			return
+		}
+		err = action(c, r)
+		if o.timeout > 0 {
+			err = timedOut(r, w, err)
+		}
+		if err != nil {
+			fail(c, w, err)
+		}
+	}), zero, &o)
+}
+
+// invoker calls an action with the controller c and the bound argument p,
+// which is the zero Value for actions that take no argument.
+type invoker func(c, p reflect.Value) error
+
+// ActionOf is a type-checked variant of Action. It takes the same method
+// expressions, but actions with an invalid signature fail to compile instead
+// of panicking when the handler is created, and requests are dispatched
+// without reflection:
+//
+//	http.Handle("/users", controller.ActionOf((*UserController).Index))
+//
+// The type parameters are inferred from the method expression: T is the
+// controller type and PT the pointer to it that implements Controller.
+func ActionOf[T any, PT interface {
+	*T
+	Controller
+}](action func(PT) error, opts ...Option) http.Handler {
+	return Handler(
+		NewFunc[T, PT](),
+		func(c Controller, r *http.Request) error {
+			return action(c.(PT))
+		},
+		append([]Option{ActionName(actionName(reflect.ValueOf(action)))}, opts...)...,
+	)
+}
+
+// ActionWith is ActionOf for actions taking a pointer to a struct as their
+// argument, which is bound from the request like for Action:
+//
+//	http.Handle("POST /users", controller.ActionWith((*UserController).Create))
+func ActionWith[T any, PT interface {
+	*T
+	Controller
+}, P any](action func(PT, *P) error, opts ...Option) http.Handler {
+	return Handler(
+		NewFunc[T, PT](),
+		func(c Controller, r *http.Request) error {
+			in := new(P)
+			if err := Bind(r, in); err != nil {
+				return err
+			}
+			return action(c.(PT), in)
+		},
+		append([]Option{ActionName(actionName(reflect.ValueOf(action)))}, opts...)...,
+	)
+}
+
+// NewFunc returns the function that constructs the controllers of type PT,
+// which is the New method of Constructor controllers. It is used by
+// ActionOf and ActionWith, and lets packages building handlers with Handler
+// construct controllers the same way:
+//
+//	controller.Handler(controller.NewFunc[UserController](), serveUser)
+func NewFunc[T any, PT interface {
+	*T
+	Controller
+}]() func() Controller {
+	if c, ok := Controller(PT(new(T))).(Constructor); ok {
+		return c.New
+	}
+	return func() Controller { return PT(new(T)) }
+}
+
+var errorType = interfaceOf((*error)(nil))
+
+// newInvoker resolves how to call action once, when the action is
+// registered: the receiver conversion and the handling of the returned error
+// are decided up front, leaving a single reflect.Call per request. ActionOf,
+// ActionWith and the handlers generated by cmd/controllergen call actions
+// without reflection.
+func newInvoker(action reflect.Value) invoker {
+	t := action.Type()
+	byValue := t.In(0).Kind() != reflect.Ptr
+	nilable := t.Out(0).Kind() == reflect.Interface || t.Out(0).Kind() == reflect.Ptr
+	args := sync.Pool{New: func() interface{} {
+		in := make([]reflect.Value, t.NumIn())
+		return &in
+	}}
+
+	return func(c, p reflect.Value) error {
+		if byValue {
+			c = c.Elem()
+		}
+		in := args.Get().(*[]reflect.Value)
+		(*in)[0] = c
+		if p.IsValid() {
+			(*in)[1] = p
+		}
+		ret := action.Call(*in)[0]
+		clear(*in)
+		args.Put(in)
+		if nilable && ret.IsNil() {
+			return nil
+		}
+		return ret.Interface().(error)
+	}
+}
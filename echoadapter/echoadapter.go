@@ -0,0 +1,36 @@
+// Package echoadapter mounts controller actions on echo servers.
+//
+// echo keeps the path parameters it matches in its own context. Action
+// copies them into the request's path values so that controllers can read
+// them with Base.Param (or Request.PathValue), the same way they would when
+// mounted on an http.ServeMux:
+//
+//	e := echo.New()
+//	e.GET("/users/:id", echoadapter.Action((*UserController).Show))
+//
+// Errors are handled by the controller, so the returned handlers never
+// return one to echo.
+package echoadapter
+
+import (
+	"github.com/codegangsta/controller"
+	"github.com/labstack/echo/v4"
+)
+
+// Action is the echo counterpart of controller.Action. The returned handler
+// exposes the echo path parameters to the controller before invoking the
+// action.
+func Action(action interface{}, opts ...controller.Option) echo.HandlerFunc {
+	h := controller.Action(action, opts...)
+	return func(c echo.Context) error {
+		r := c.Request()
+		values := c.ParamValues()
+		for i, name := range c.ParamNames() {
+			if i < len(values) {
+				r.SetPathValue(name, values[i])
+			}
+		}
+		h.ServeHTTP(c.Response(), r)
+		return nil
+	}
+}
@@ -0,0 +1,29 @@
+package echoadapter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/codegangsta/controller"
+	"github.com/labstack/echo/v4"
+)
+
+type UserController struct {
+	controller.Base
+}
+
+func (c *UserController) Show() error {
+	return c.Text(http.StatusOK, "user "+c.Request.PathValue("id"))
+}
+
+func TestAction(t *testing.T) {
+	r := echo.New()
+	r.GET("/users/:id", Action((*UserController).Show))
+
+	rw := httptest.NewRecorder()
+	r.ServeHTTP(rw, httptest.NewRequest("GET", "/users/42", nil))
+	if rw.Code != http.StatusOK || rw.Body.String() != "user 42" {
+		t.Errorf("Expected 200 \"user 42\", got %d %q", rw.Code, rw.Body.String())
+	}
+}
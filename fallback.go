@@ -0,0 +1,50 @@
+package controller
+
+import "net/http"
+
+// Fallback returns a handler that routes requests with mux, and hands requests
+// that do not match any route to the given action, a method expression as
+// accepted by Action. This gives 404 pages the same lifecycle (Init, Destroy,
+// error handling) as every other request:
+//
+//	http.ListenAndServe(":3000", controller.Fallback(mux, (*ErrorsController).NotFound))
+//
+// The response status defaults to 404 Not Found, so the fallback action only
+// has to write the body, if any. Requests for a routed path with a method it is not
+// routed for are still answered by mux with 405 Method Not Allowed.
+func Fallback(mux *http.ServeMux, action interface{}) http.Handler {
+	fallback := Action(action)
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if _, pattern := mux.Handler(r); pattern != "" || allowedMethods(mux, r) != nil {
+			mux.ServeHTTP(rw, r)
+			return
+		}
+		w := &notFoundWriter{ResponseWriter: rw}
+		fallback.ServeHTTP(w, r)
+		if !w.wroteHeader {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+}
+
+// notFoundWriter is a ResponseWriter whose status defaults to 404 Not Found.
+type notFoundWriter struct {
+	http.ResponseWriter
+	wroteHeader bool
+}
+
+func (w *notFoundWriter) WriteHeader(code int) {
+	w.wroteHeader = true
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *notFoundWriter) Write(p []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusNotFound)
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+func (w *notFoundWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
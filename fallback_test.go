@@ -0,0 +1,55 @@
+package controller
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type ErrorsController struct {
+	Base
+}
+
+func (c *ErrorsController) NotFound() error {
+	c.ResponseWriter.Write([]byte("no such page: " + c.Request.URL.Path))
+	return nil
+}
+
+func (c *ErrorsController) Empty() error {
+	return nil
+}
+
+func TestFallback(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /users", func(rw http.ResponseWriter, r *http.Request) {
+		rw.Write([]byte("users"))
+	})
+	h := Fallback(mux, (*ErrorsController).NotFound)
+
+	var tests = []struct {
+		method string
+		path   string
+		code   int
+		body   string
+	}{
+		{"GET", "/users", http.StatusOK, "users"},
+		{"GET", "/missing", http.StatusNotFound, "no such page: /missing"},
+		{"DELETE", "/users", http.StatusMethodNotAllowed, ""},
+	}
+
+	for _, test := range tests {
+		rw := httptest.NewRecorder()
+		h.ServeHTTP(rw, httptest.NewRequest(test.method, test.path, nil))
+		equals(t, test.code, rw.Code)
+		if test.body != "" {
+			equals(t, test.body, rw.Body.String())
+		}
+	}
+}
+
+func TestFallbackEmpty(t *testing.T) {
+	rw := httptest.NewRecorder()
+	Fallback(http.NewServeMux(), (*ErrorsController).Empty).ServeHTTP(rw, httptest.NewRequest("GET", "/missing", nil))
+	equals(t, http.StatusNotFound, rw.Code)
+	equals(t, "", rw.Body.String())
+}
@@ -0,0 +1,61 @@
+// Package fasthttpadapter serves controller actions with fasthttp.
+//
+// Action converts the fasthttp.RequestCtx of every request into the
+// *http.Request and http.ResponseWriter controllers are initialized with,
+// so controllers keep their structure and lifecycle:
+//
+//	r := router.New()
+//	r.GET("/users/{id}", fasthttpadapter.Action((*UserController).Show))
+//	fasthttp.ListenAndServe(":8080", r.Handler)
+//
+// String user values of the RequestCtx, where routers such as fasthttp/router
+// store path parameters, become path values of the request, so controllers
+// read them with Base.Param (or Request.PathValue). Controllers that need the
+// RequestCtx itself embed Controller.
+package fasthttpadapter
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/codegangsta/controller"
+	"github.com/valyala/fasthttp"
+	"github.com/valyala/fasthttp/fasthttpadaptor"
+)
+
+type requestCtxKey struct{}
+
+// Action is the fasthttp counterpart of controller.Action.
+func Action(action interface{}, opts ...controller.Option) fasthttp.RequestHandler {
+	h := controller.Action(action, opts...)
+	return fasthttpadaptor.NewFastHTTPHandler(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if ctx, ok := r.Context().(*fasthttp.RequestCtx); ok {
+			ctx.VisitUserValues(func(key []byte, value interface{}) {
+				if s, ok := value.(string); ok {
+					r.SetPathValue(string(key), s)
+				}
+			})
+			r = r.WithContext(context.WithValue(ctx, requestCtxKey{}, ctx))
+		}
+		h.ServeHTTP(rw, r)
+	}))
+}
+
+// RequestCtx returns the fasthttp.RequestCtx r has been converted from, and
+// whether there is one.
+func RequestCtx(r *http.Request) (*fasthttp.RequestCtx, bool) {
+	ctx, ok := r.Context().Value(requestCtxKey{}).(*fasthttp.RequestCtx)
+	return ctx, ok
+}
+
+// Controller is a controller.Base for controllers served with fasthttp.
+type Controller struct {
+	controller.Base
+}
+
+// RequestCtx returns the fasthttp.RequestCtx of the request, or nil if the
+// action is not served by a handler returned by Action.
+func (c *Controller) RequestCtx() *fasthttp.RequestCtx {
+	ctx, _ := RequestCtx(c.Request)
+	return ctx
+}
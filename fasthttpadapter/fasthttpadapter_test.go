@@ -0,0 +1,31 @@
+package fasthttpadapter
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/valyala/fasthttp"
+)
+
+type UserController struct {
+	Controller
+}
+
+func (c *UserController) Show() error {
+	c.RequestCtx().Response.Header.Set("X-Fasthttp", "1")
+	return c.Text(http.StatusOK, "user "+c.Request.PathValue("id"))
+}
+
+func TestAction(t *testing.T) {
+	var ctx fasthttp.RequestCtx
+	ctx.Request.SetRequestURI("/users/42")
+	ctx.SetUserValue("id", "42")
+	Action((*UserController).Show)(&ctx)
+
+	if code, body := ctx.Response.StatusCode(), string(ctx.Response.Body()); code != http.StatusOK || body != "user 42" {
+		t.Errorf("Expected 200 \"user 42\", got %d %q", code, body)
+	}
+	if got := string(ctx.Response.Header.Peek("X-Fasthttp")); got != "1" {
+		t.Errorf("Expected the RequestCtx to be available, got header %q", got)
+	}
+}
@@ -0,0 +1,62 @@
+package controller
+
+import "net/http"
+
+// Filter checks or prepares a request after the controller has been
+// initialized and before the action is invoked, for concerns such as
+// authentication or CSRF protection that apply to many actions. If a filter
+// returns an error, the remaining filters and the action are skipped and the
+// error is reported through the Error method of the controller, like an
+// error returned by the action.
+//
+// r is the request of the controller, which is the request it was
+// initialized with unless Init has replaced it, for example with
+// Base.WithValue.
+type Filter func(c Controller, rw http.ResponseWriter, r *http.Request) error
+
+// FilterProvider is implemented by controllers that declare filters for all
+// of their actions:
+//
+//	func (*AdminController) ActionFilters() []controller.Filter {
+//		return []controller.Filter{requireAdmin, csrf.Protect(nil)}
+//	}
+//
+// ActionFilters is called once on a zero value of the controller when an
+// action is created. The filters run in order.
+type FilterProvider interface {
+	ActionFilters() []Filter
+}
+
+// UseFilters runs filters for a single action, after the filters declared
+// by the controller with FilterProvider.
+func UseFilters(filters ...Filter) Option {
+	return func(o *options) {
+		o.filters = append(o.filters, filters...)
+	}
+}
+
+// filtersFor returns the filters of an action with controllers like c.
+func (o *options) filtersFor(c Controller) []Filter {
+	var filters []Filter
+	if p, ok := c.(FilterProvider); ok {
+		filters = append(filters, p.ActionFilters()...)
+	}
+	return append(filters, o.filters...)
+}
+
+// runFilters runs filters for the controller c, which was initialized with
+// the request r.
+func runFilters(filters []Filter, c Controller, rw http.ResponseWriter, r *http.Request) error {
+	if len(filters) == 0 {
+		return nil
+	}
+	if b, ok := c.(baser); ok && b.base().Request != nil {
+		r = b.base().Request
+	}
+	for _, filter := range filters {
+		if err := filter(c, rw, r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type filterKey struct{}
+
+type FilterController struct {
+	Base
+}
+
+func (c *FilterController) Init(rw http.ResponseWriter, r *http.Request) error {
+	c.Base.Init(rw, r)
+	c.WithValue(filterKey{}, "from init")
+	return nil
+}
+
+func (*FilterController) ActionFilters() []Filter {
+	return []Filter{func(c Controller, rw http.ResponseWriter, r *http.Request) error {
+		rw.Header().Add("X-Filter", r.Context().Value(filterKey{}).(string))
+		return nil
+	}}
+}
+
+func (c *FilterController) Index() error {
+	return c.Text(http.StatusOK, "index")
+}
+
+func TestActionFilters(t *testing.T) {
+	deny := func(c Controller, rw http.ResponseWriter, r *http.Request) error {
+		if r.URL.Query().Get("deny") != "" {
+			return &HTTPError{Code: http.StatusForbidden, Err: errors.New("Denied")}
+		}
+		rw.Header().Add("X-Filter", "action")
+		return nil
+	}
+	for _, h := range []http.Handler{
+		Action((*FilterController).Index, UseFilters(deny)),
+		ActionOf((*FilterController).Index, UseFilters(deny)),
+	} {
+		rw := httptest.NewRecorder()
+		h.ServeHTTP(rw, httptest.NewRequest("GET", "/", nil))
+		equals(t, "index", rw.Body.String())
+		equals(t, []string{"from init", "action"}, rw.Header()["X-Filter"])
+
+		rw = httptest.NewRecorder()
+		h.ServeHTTP(rw, httptest.NewRequest("GET", "/?deny=1", nil))
+		equals(t, http.StatusForbidden, rw.Code)
+		equals(t, "Denied\n", rw.Body.String())
+	}
+}
@@ -0,0 +1,28 @@
+// Package ginadapter mounts controller actions on gin engines.
+//
+// gin keeps the path parameters it matches in its own context. Action copies
+// them into the request's path values so that controllers can read them with
+// Base.Param (or Request.PathValue), the same way they would when mounted on
+// an http.ServeMux:
+//
+//	r := gin.New()
+//	r.GET("/users/:id", ginadapter.Action((*UserController).Show))
+package ginadapter
+
+import (
+	"github.com/codegangsta/controller"
+	"github.com/gin-gonic/gin"
+)
+
+// Action is the gin counterpart of controller.Action. The returned handler
+// exposes the gin path parameters to the controller before invoking the
+// action.
+func Action(action interface{}, opts ...controller.Option) gin.HandlerFunc {
+	h := controller.Action(action, opts...)
+	return func(c *gin.Context) {
+		for _, param := range c.Params {
+			c.Request.SetPathValue(param.Key, param.Value)
+		}
+		h.ServeHTTP(c.Writer, c.Request)
+	}
+}
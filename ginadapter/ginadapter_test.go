@@ -0,0 +1,30 @@
+package ginadapter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/codegangsta/controller"
+	"github.com/gin-gonic/gin"
+)
+
+type UserController struct {
+	controller.Base
+}
+
+func (c *UserController) Show() error {
+	return c.Text(http.StatusOK, "user "+c.Request.PathValue("id"))
+}
+
+func TestAction(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/users/:id", Action((*UserController).Show))
+
+	rw := httptest.NewRecorder()
+	r.ServeHTTP(rw, httptest.NewRequest("GET", "/users/42", nil))
+	if rw.Code != http.StatusOK || rw.Body.String() != "user 42" {
+		t.Errorf("Expected 200 \"user 42\", got %d %q", rw.Code, rw.Body.String())
+	}
+}
@@ -0,0 +1,141 @@
+// Package graphql provides a controller base for GraphQL endpoints, on top
+// of github.com/graphql-go/graphql.
+//
+// GraphQL controllers embed Controller, which executes the operation of the
+// request with the injected schema. Init prepares what resolvers need for a
+// single request, such as data loaders, by adding it to the request context
+// that is handed to resolvers:
+//
+//	type APIController struct {
+//		graphql.Controller
+//		Users *store.Users `inject:""`
+//	}
+//
+//	func (c *APIController) Init(rw http.ResponseWriter, r *http.Request) error {
+//		c.Controller.Init(rw, r)
+//		c.WithValue(loadersKey{}, newLoaders(c.Users))
+//		return nil
+//	}
+//
+//	controller.Provide(&schema)
+//	mux.Handle("/graphql", controller.Action((*APIController).Execute))
+package graphql
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+
+	"github.com/codegangsta/controller"
+	gql "github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+)
+
+// Request is a GraphQL request, as sent in the JSON body of POST requests or
+// the query string of GET requests.
+type Request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName,omitempty"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+}
+
+// Controller is a controller.Base that executes GraphQL requests.
+type Controller struct {
+	controller.Base
+
+	// Schema is the schema requests are executed with. It is injected with
+	// the *graphql.Schema registered with controller.Provide or provided to
+	// the App of the action.
+	Schema *gql.Schema `inject:""`
+	// RootObject, if set, is handed to the resolvers of the top level fields.
+	RootObject map[string]interface{}
+}
+
+// Execute binds the GraphQL request, executes it with the request context
+// and renders the result as JSON, with the data and errors members defined
+// by the GraphQL specification. Errors of the operation itself are part of
+// the result; requests that are not valid GraphQL requests are answered
+// through the Error method of the controller, with 400 Bad Request, or 405
+// Method Not Allowed for mutations sent with GET.
+func (c *Controller) Execute() error {
+	var req Request
+	if err := c.BindRequest(&req); err != nil {
+		return err
+	}
+	result := gql.Do(gql.Params{
+		Schema:         *c.Schema,
+		RequestString:  req.Query,
+		RootObject:     c.RootObject,
+		VariableValues: req.Variables,
+		OperationName:  req.OperationName,
+		Context:        c.Context(),
+	})
+	return c.JSON(http.StatusOK, result)
+}
+
+// BindRequest binds req from the query string of GET requests, and from the
+// body of other requests, which is either JSON or an application/graphql
+// document.
+func (c *Controller) BindRequest(req *Request) error {
+	r := c.Request
+	switch {
+	case r.Method == http.MethodGet || r.Method == http.MethodHead:
+		q := r.URL.Query()
+		req.Query, req.OperationName = q.Get("query"), q.Get("operationName")
+		if variables := q.Get("variables"); variables != "" {
+			if err := json.Unmarshal([]byte(variables), &req.Variables); err != nil {
+				return &controller.HTTPError{Code: http.StatusBadRequest, Err: fmt.Errorf("Invalid variables: %v", err)}
+			}
+		}
+		if isMutation(req) {
+			return &controller.HTTPError{Code: http.StatusMethodNotAllowed, Err: errors.New("Mutations must be sent with POST")}
+		}
+	case isGraphQL(r):
+		body, err := io.ReadAll(r.Body)
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return &controller.HTTPError{Code: http.StatusRequestEntityTooLarge, Err: err}
+		}
+		if err != nil {
+			return &controller.HTTPError{Code: http.StatusBadRequest, Err: err}
+		}
+		req.Query = string(body)
+	default:
+		if err := c.BindJSON(req); err != nil {
+			return err
+		}
+	}
+	if req.Query == "" {
+		return &controller.HTTPError{Code: http.StatusBadRequest, Err: errors.New("Missing query")}
+	}
+	return nil
+}
+
+// isGraphQL reports whether r has an application/graphql body.
+func isGraphQL(r *http.Request) bool {
+	t, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return err == nil && t == "application/graphql"
+}
+
+// isMutation reports whether the operation req asks for is a mutation.
+// Documents that do not parse are left to the execution to report.
+func isMutation(req *Request) bool {
+	doc, err := parser.Parse(parser.ParseParams{Source: req.Query})
+	if err != nil {
+		return false
+	}
+	for _, def := range doc.Definitions {
+		op, ok := def.(*ast.OperationDefinition)
+		if !ok {
+			continue
+		}
+		if req.OperationName == "" || op.Name != nil && op.Name.Value == req.OperationName {
+			return op.Operation == ast.OperationTypeMutation
+		}
+	}
+	return false
+}
@@ -0,0 +1,93 @@
+package graphql
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/codegangsta/controller"
+	gql "github.com/graphql-go/graphql"
+)
+
+type greetingKey struct{}
+
+type APIController struct {
+	Controller
+}
+
+func (c *APIController) Init(rw http.ResponseWriter, r *http.Request) error {
+	c.Controller.Init(rw, r)
+	c.WithValue(greetingKey{}, "hello")
+	return nil
+}
+
+func newSchema(t *testing.T) *gql.Schema {
+	greet := func(p gql.ResolveParams) (interface{}, error) {
+		return p.Context.Value(greetingKey{}).(string) + " " + p.Args["name"].(string), nil
+	}
+	args := gql.FieldConfigArgument{"name": &gql.ArgumentConfig{Type: gql.String}}
+	schema, err := gql.NewSchema(gql.SchemaConfig{
+		Query: gql.NewObject(gql.ObjectConfig{Name: "Query", Fields: gql.Fields{
+			"greet": &gql.Field{Type: gql.String, Args: args, Resolve: greet},
+		}}),
+		Mutation: gql.NewObject(gql.ObjectConfig{Name: "Mutation", Fields: gql.Fields{
+			"greet": &gql.Field{Type: gql.String, Args: args, Resolve: greet},
+		}}),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &schema
+}
+
+func TestController(t *testing.T) {
+	app := &controller.App{}
+	app.Provide(newSchema(t))
+	h := app.Action((*APIController).Execute)
+
+	get := func(query, variables string) *http.Request {
+		return httptest.NewRequest("GET", "/graphql?"+url.Values{"query": {query}, "variables": {variables}}.Encode(), nil)
+	}
+	post := func(contentType, body string) *http.Request {
+		r := httptest.NewRequest("POST", "/graphql", strings.NewReader(body))
+		r.Header.Set("Content-Type", contentType)
+		return r
+	}
+	var tests = []struct {
+		r    *http.Request
+		code int
+		body string
+	}{
+		{post("application/json", `{"query":"query($n: String) { greet(name: $n) }","variables":{"n":"gopher"}}`), http.StatusOK, `{"data":{"greet":"hello gopher"}}`},
+		{post("application/graphql", `{ greet(name: "gopher") }`), http.StatusOK, `{"data":{"greet":"hello gopher"}}`},
+		{get(`query($n: String) { greet(name: $n) }`, `{"n":"gopher"}`), http.StatusOK, `{"data":{"greet":"hello gopher"}}`},
+		{get(`mutation { greet(name: "gopher") }`, ""), http.StatusMethodNotAllowed, "Mutations must be sent with POST\n"},
+		{post("application/json", `{}`), http.StatusBadRequest, "Missing query\n"},
+		{post("application/json", `{"query":"{ unknown }"}`), http.StatusOK, `"errors"`},
+	}
+	for _, test := range tests {
+		rw := httptest.NewRecorder()
+		h.ServeHTTP(rw, test.r)
+		if rw.Code != test.code || !strings.Contains(rw.Body.String(), test.body) {
+			t.Errorf("%s %s: expected %d %s, got %d %s", test.r.Method, test.r.URL, test.code, test.body, rw.Code, rw.Body.String())
+		}
+	}
+}
+
+func TestBodyTooLarge(t *testing.T) {
+	app := &controller.App{}
+	app.Provide(newSchema(t))
+	h := app.Action((*APIController).Execute, controller.MaxBodyBytes(16))
+
+	// The body has no announced length, so reading it hits the limit.
+	r := httptest.NewRequest("POST", "/graphql", io.MultiReader(strings.NewReader(`{ greet(name: "a long name") }`)))
+	r.Header.Set("Content-Type", "application/graphql")
+	rw := httptest.NewRecorder()
+	h.ServeHTTP(rw, r)
+	if rw.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("Expected 413, got %d %s", rw.Code, rw.Body.String())
+	}
+}
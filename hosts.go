@@ -0,0 +1,97 @@
+package controller
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// Hosts dispatches requests to handlers by the host they are addressed to,
+// which allows a single server to run several applications (or several route
+// groups of one application) side by side:
+//
+//	api := http.NewServeMux()
+//	controller.Mount(api, "/", (*APIController)(nil))
+//
+//	hosts := new(controller.Hosts)
+//	hosts.Handle("api.example.com", api)
+//	hosts.Handle("*.example.com", controller.Action((*SiteController).Index))
+//
+// Patterns are either exact host names or wildcards starting with "*.", which
+// match any subdomain of the rest of the pattern. The part of the host
+// matched by the wildcard is available to controllers through
+// Base.Subdomain. Exact patterns take precedence over wildcards, and longer
+// wildcards over shorter ones. The pattern "*" matches every host.
+//
+// Requests not matching any pattern are answered with 404 Not Found.
+type Hosts struct {
+	exact    map[string]http.Handler
+	wildcard []hostPattern
+	fallback http.Handler
+}
+
+type hostPattern struct {
+	suffix  string
+	handler http.Handler
+}
+
+// Handle registers handler for the given host pattern.
+func (h *Hosts) Handle(pattern string, handler http.Handler) {
+	pattern = strings.ToLower(pattern)
+	switch {
+	case pattern == "*":
+		h.fallback = handler
+	case strings.HasPrefix(pattern, "*."):
+		p := hostPattern{suffix: pattern[1:], handler: handler}
+		i := 0
+		for i < len(h.wildcard) && len(h.wildcard[i].suffix) >= len(p.suffix) {
+			i++
+		}
+		h.wildcard = append(h.wildcard, hostPattern{})
+		copy(h.wildcard[i+1:], h.wildcard[i:])
+		h.wildcard[i] = p
+	default:
+		if h.exact == nil {
+			h.exact = make(map[string]http.Handler)
+		}
+		h.exact[pattern] = handler
+	}
+}
+
+// ServeHTTP dispatches the request to the handler registered for its host.
+func (h *Hosts) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	host := strings.ToLower(r.Host)
+	if name, _, err := net.SplitHostPort(host); err == nil {
+		host = name
+	}
+
+	if handler, ok := h.exact[host]; ok {
+		handler.ServeHTTP(rw, r)
+		return
+	}
+
+	for _, p := range h.wildcard {
+		if sub := strings.TrimSuffix(host, p.suffix); sub != host && sub != "" {
+			ctx := context.WithValue(r.Context(), subdomainKey{}, sub)
+			p.handler.ServeHTTP(rw, r.WithContext(ctx))
+			return
+		}
+	}
+
+	if h.fallback != nil {
+		h.fallback.ServeHTTP(rw, r)
+		return
+	}
+	http.NotFound(rw, r)
+}
+
+type subdomainKey struct{}
+
+// Subdomain returns the part of the request host matched by the wildcard of
+// a Hosts pattern, or an empty string if the request was not routed through
+// a wildcard pattern.
+func (b *Base) Subdomain() string {
+	sub, _ := b.Request.Context().Value(subdomainKey{}).(string)
+	return sub
+}
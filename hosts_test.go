@@ -0,0 +1,50 @@
+package controller
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type HostController struct {
+	Base
+}
+
+func (c *HostController) Index() error {
+	c.ResponseWriter.Write([]byte("site " + c.Subdomain()))
+	return nil
+}
+
+func TestHosts(t *testing.T) {
+	hosts := new(Hosts)
+	hosts.Handle("api.example.com", http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.Write([]byte("api"))
+	}))
+	hosts.Handle("*.example.com", Action((*HostController).Index))
+	hosts.Handle("*.eu.example.com", Action((*HostController).Index))
+
+	var tests = []struct {
+		host string
+		code int
+		body string
+	}{
+		{"api.example.com", http.StatusOK, "api"},
+		{"API.example.com:8080", http.StatusOK, "api"},
+		{"www.example.com", http.StatusOK, "site www"},
+		{"shop.eu.example.com", http.StatusOK, "site shop"},
+		{"a.b.example.com", http.StatusOK, "site a.b"},
+		{"example.com", http.StatusNotFound, ""},
+		{"example.org", http.StatusNotFound, ""},
+	}
+
+	for _, test := range tests {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Host = test.host
+		rw := httptest.NewRecorder()
+		hosts.ServeHTTP(rw, r)
+		equals(t, test.code, rw.Code)
+		if test.body != "" {
+			equals(t, test.body, rw.Body.String())
+		}
+	}
+}
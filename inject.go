@@ -0,0 +1,300 @@
+package controller
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"sync"
+)
+
+// registry holds the providers of values by the type of the values.
+type registry struct {
+	mu     sync.RWMutex
+	values map[reflect.Type]*provider
+}
+
+// providers holds the values registered with Provide and ProvideFunc.
+var providers registry
+
+// Provide registers value for injection into controllers. Before Init is
+// called, Action sets every exported field of the controller that is tagged
+// with `inject:""` to the provided value of the same type, so controllers
+// receive their dependencies without global variables:
+//
+//	type UserController struct {
+//		controller.Base
+//		DB     *sql.DB      `inject:""`
+//		Mailer mail.Sender  `inject:""`
+//	}
+//
+//	controller.Provide(db)
+//	controller.Provide(smtpSender)
+//
+// Fields with an interface type also accept a provided value implementing
+// the interface, as long as there is only one. Providing a value of a type
+// that has been provided before replaces it. A request for a controller with
+// a field no value has been provided for fails with 500 Internal Server
+// Error.
+func Provide(value interface{}) {
+	providers.provide(value)
+}
+
+// Scope is the lifetime of the values constructed by a function registered
+// with ProvideFunc.
+type Scope int
+
+const (
+	// Singleton values are constructed once, when a controller first needs
+	// one, and shared by all controllers afterwards.
+	Singleton Scope = iota
+	// RequestScope values are constructed for every controller that needs
+	// one. Values implementing io.Closer are closed after Destroy.
+	RequestScope
+)
+
+// ProvideFunc registers constructor to construct values for injection into
+// controllers, like Provide does for ready-made values. The constructor
+// returns the value, optionally followed by an error, and request scoped
+// constructors may take the request the value is constructed for as their
+// only argument:
+//
+//	controller.ProvideFunc(loadTemplates, controller.Singleton)
+//	controller.ProvideFunc(func(r *http.Request) (*sql.Conn, error) {
+//		return db.Conn(r.Context())
+//	}, controller.RequestScope)
+//
+// Requests for which a constructor fails are answered with 500 Internal
+// Server Error. Failed singletons are constructed again for the next
+// request. ProvideFunc panics if constructor is not a valid constructor.
+func ProvideFunc(constructor interface{}, scope Scope) {
+	providers.provideFunc(constructor, scope)
+}
+
+// provider provides the values of a type.
+type provider struct {
+	// value is the provided value, or the singleton once constructed.
+	value reflect.Value
+	// construct is the constructor registered with ProvideFunc, if any.
+	construct reflect.Value
+	scope     Scope
+	mu        sync.Mutex
+}
+
+func (r *registry) provide(value interface{}) {
+	if value == nil {
+		panic("controller: Provide called with nil")
+	}
+	r.register(reflect.TypeOf(value), &provider{value: reflect.ValueOf(value)})
+}
+
+func (r *registry) provideFunc(constructor interface{}, scope Scope) {
+	fn := reflect.ValueOf(constructor)
+	t := fn.Type()
+	if t.Kind() != reflect.Func {
+		panic(fmt.Sprintf("controller: ProvideFunc called with %s, not a function", t))
+	}
+	if t.NumIn() > 1 || t.NumIn() == 1 && (scope != RequestScope || t.In(0) != requestType) {
+		panic(fmt.Sprintf("controller: ProvideFunc called with %s, which takes invalid arguments", t))
+	}
+	if t.NumOut() != 1 && (t.NumOut() != 2 || t.Out(1) != errorType) {
+		panic(fmt.Sprintf("controller: ProvideFunc called with %s, which returns invalid values", t))
+	}
+	r.register(t.Out(0), &provider{construct: fn, scope: scope})
+}
+
+func (r *registry) register(t reflect.Type, p *provider) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.values == nil {
+		r.values = make(map[reflect.Type]*provider)
+	}
+	r.values[t] = p
+}
+
+var requestType = reflect.TypeOf((*http.Request)(nil))
+
+// lookup returns the provider of the values for a field of type t.
+func (r *registry) lookup(t reflect.Type) (*provider, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if p, ok := r.values[t]; ok {
+		return p, nil
+	}
+
+	var match *provider
+	if t.Kind() == reflect.Interface {
+		for pt, p := range r.values {
+			if !pt.Implements(t) {
+				continue
+			}
+			if match != nil {
+				return nil, fmt.Errorf("Several provided values implement %s", t)
+			}
+			match = p
+		}
+	}
+	if match == nil {
+		return nil, fmt.Errorf("No value provided for %s", t)
+	}
+	return match, nil
+}
+
+// get returns the value for a field of type t of a controller serving req,
+// and the closer to call after Destroy for request scoped values. Values can
+// not be constructed for request scoped providers if req is nil.
+func (r *registry) get(t reflect.Type, req *http.Request) (reflect.Value, io.Closer, error) {
+	p, err := r.lookup(t)
+	if err != nil {
+		return reflect.Value{}, nil, err
+	}
+	if !p.construct.IsValid() {
+		return p.value, nil, nil
+	}
+	if p.scope == Singleton {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		if !p.value.IsValid() {
+			if p.value, err = p.call(nil); err != nil {
+				return reflect.Value{}, nil, err
+			}
+		}
+		return p.value, nil, nil
+	}
+
+	if req == nil {
+		return reflect.Value{}, nil, fmt.Errorf("Can not construct request scoped %s without a request", t)
+	}
+	value, err := p.call(req)
+	if err != nil {
+		return reflect.Value{}, nil, err
+	}
+	closer, _ := value.Interface().(io.Closer)
+	return value, closer, nil
+}
+
+// call calls the constructor of p.
+func (p *provider) call(req *http.Request) (reflect.Value, error) {
+	var in []reflect.Value
+	if p.construct.Type().NumIn() == 1 {
+		in = []reflect.Value{reflect.ValueOf(req)}
+	}
+	out := p.construct.Call(in)
+	if len(out) == 2 && !out[1].IsNil() {
+		return reflect.Value{}, out[1].Interface().(error)
+	}
+	return out[0], nil
+}
+
+// Injector resolves the dependencies of controllers. It lets dependency
+// injection containers such as dig, or providers generated by wire,
+// construct the values of the fields tagged with `inject:""` in place of
+// the values registered with Provide. Resolve is called for every field of
+// every controller constructed, so it can create request scoped values.
+type Injector interface {
+	// Resolve returns the value for a field of type t.
+	Resolve(t reflect.Type) (interface{}, error)
+}
+
+// InjectorFunc is an adapter to use ordinary functions as an Injector.
+type InjectorFunc func(t reflect.Type) (interface{}, error)
+
+// Resolve calls f(t).
+func (f InjectorFunc) Resolve(t reflect.Type) (interface{}, error) {
+	return f(t)
+}
+
+// UseInjector makes an action resolve the dependencies of its controllers
+// with injector instead of the values registered with Provide:
+//
+//	controller.Action((*UserController).Show, controller.UseInjector(controller.InjectorFunc(
+//		func(t reflect.Type) (interface{}, error) {
+//			return container.Resolve(t)
+//		},
+//	)))
+func UseInjector(injector Injector) Option {
+	return func(o *options) {
+		o.injector = injector
+	}
+}
+
+// injectFields caches the indices of the fields tagged for injection per
+// controller type.
+var injectFields sync.Map // map[reflect.Type][][]int
+
+// fieldsToInject returns the indices of the fields of the struct type t that
+// are tagged for injection.
+func fieldsToInject(t reflect.Type) [][]int {
+	if fields, ok := injectFields.Load(t); ok {
+		return fields.([][]int)
+	}
+	var fields [][]int
+	for _, field := range reflect.VisibleFields(t) {
+		if _, ok := field.Tag.Lookup("inject"); ok && field.IsExported() {
+			fields = append(fields, field.Index)
+		}
+	}
+	injectFields.Store(t, fields)
+	return fields
+}
+
+// inject sets the fields of the controller c serving r that are tagged for
+// injection to the values resolved by the Injector of the action, or else
+// provided to its App or registered with Provide. It returns the request
+// scoped values to close after Destroy.
+func inject(c Controller, o *options, r *http.Request) ([]io.Closer, error) {
+	v := reflect.ValueOf(c)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return nil, nil
+	}
+	v = v.Elem()
+	reg := &providers
+	if o.app != nil {
+		reg = &o.app.providers
+	}
+	var closers []io.Closer
+	for _, index := range fieldsToInject(v.Type()) {
+		field, err := v.FieldByIndexErr(index)
+		var (
+			value  reflect.Value
+			closer io.Closer
+		)
+		if err == nil {
+			if o.injector != nil {
+				value, err = resolve(o.injector, field.Type())
+			} else {
+				value, closer, err = reg.get(field.Type(), r)
+			}
+		}
+		if err != nil {
+			closeAll(closers)
+			return nil, fmt.Errorf("Can not inject %s.%s: %w", v.Type(), v.Type().FieldByIndex(index).Name, err)
+		}
+		if closer != nil {
+			closers = append(closers, closer)
+		}
+		field.Set(value)
+	}
+	return closers, nil
+}
+
+// closeAll closes the request scoped values of a controller, in the reverse
+// order of their construction.
+func closeAll(closers []io.Closer) {
+	for i := len(closers) - 1; i >= 0; i-- {
+		closers[i].Close()
+	}
+}
+
+// resolve resolves a value for a field of type t with injector.
+func resolve(injector Injector, t reflect.Type) (reflect.Value, error) {
+	resolved, err := injector.Resolve(t)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	value := reflect.ValueOf(resolved)
+	if !value.IsValid() || !value.Type().AssignableTo(t) {
+		return reflect.Value{}, fmt.Errorf("Injector resolved %T for %s", resolved, t)
+	}
+	return value, nil
+}
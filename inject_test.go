@@ -0,0 +1,165 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+type greeter interface {
+	Greet(name string) string
+}
+
+type englishGreeter struct{}
+
+func (englishGreeter) Greet(name string) string { return "hello " + name }
+
+type injectConfig struct {
+	Env string
+}
+
+type InjectController struct {
+	Base
+	Config  *injectConfig `inject:""`
+	Greeter greeter       `inject:""`
+	Other   *injectConfig
+}
+
+func (c *InjectController) Init(rw http.ResponseWriter, r *http.Request) error {
+	if c.Config == nil {
+		return fmt.Errorf("Config not injected before Init")
+	}
+	return c.Base.Init(rw, r)
+}
+
+func (c *InjectController) Index() error {
+	return c.Textf(http.StatusOK, "%s in %s, other: %v", c.Greeter.Greet("gopher"), c.Config.Env, c.Other)
+}
+
+func TestProvide(t *testing.T) {
+	defer func() {
+		providers.mu.Lock()
+		providers.values = nil
+		providers.mu.Unlock()
+	}()
+
+	h := Action((*InjectController).Index)
+
+	// Fields without a provided value fail the request.
+	rw := httptest.NewRecorder()
+	h.ServeHTTP(rw, httptest.NewRequest("GET", "/", nil))
+	equals(t, http.StatusInternalServerError, rw.Code)
+
+	Provide(&injectConfig{Env: "test"})
+	Provide(englishGreeter{})
+	for _, h := range []http.Handler{h, ActionOf((*InjectController).Index)} {
+		rw = httptest.NewRecorder()
+		h.ServeHTTP(rw, httptest.NewRequest("GET", "/", nil))
+		equals(t, http.StatusOK, rw.Code)
+		equals(t, "hello gopher in test, other: <nil>", rw.Body.String())
+	}
+}
+
+func TestUseInjector(t *testing.T) {
+	requests := 0
+	injector := InjectorFunc(func(t reflect.Type) (interface{}, error) {
+		switch t {
+		case reflect.TypeOf(&injectConfig{}):
+			requests++
+			return &injectConfig{Env: fmt.Sprintf("request %d", requests)}, nil
+		case reflect.TypeOf((*greeter)(nil)).Elem():
+			return englishGreeter{}, nil
+		}
+		return nil, fmt.Errorf("Unknown type %s", t)
+	})
+
+	h := Action((*InjectController).Index, UseInjector(injector))
+	for i := 1; i <= 2; i++ {
+		rw := httptest.NewRecorder()
+		h.ServeHTTP(rw, httptest.NewRequest("GET", "/", nil))
+		equals(t, fmt.Sprintf("hello gopher in request %d, other: <nil>", i), rw.Body.String())
+	}
+
+	// Values of the wrong type are rejected.
+	rw := httptest.NewRecorder()
+	wrong := InjectorFunc(func(t reflect.Type) (interface{}, error) { return "config", nil })
+	Action((*InjectController).Index, UseInjector(wrong)).ServeHTTP(rw, httptest.NewRequest("GET", "/", nil))
+	equals(t, http.StatusInternalServerError, rw.Code)
+}
+
+type session struct {
+	id     int
+	events *[]string
+}
+
+func (s *session) Close() error {
+	*s.events = append(*s.events, fmt.Sprintf("close %d", s.id))
+	return nil
+}
+
+type ScopedController struct {
+	Base
+	Config  *injectConfig `inject:""`
+	Session *session      `inject:""`
+}
+
+func (c *ScopedController) Destroy() {
+	*c.Session.events = append(*c.Session.events, fmt.Sprintf("destroy %d", c.Session.id))
+}
+
+func (c *ScopedController) Index() error {
+	return c.Textf(http.StatusOK, "%s %d", c.Config.Env, c.Session.id)
+}
+
+func TestProvideFunc(t *testing.T) {
+	var (
+		configs, sessions int
+		events            []string
+	)
+	app := &App{}
+	app.ProvideFunc(func() *injectConfig {
+		configs++
+		return &injectConfig{Env: "test"}
+	}, Singleton)
+	app.ProvideFunc(func(r *http.Request) (*session, error) {
+		if r.URL.Query().Get("fail") != "" {
+			return nil, fmt.Errorf("No session")
+		}
+		sessions++
+		return &session{id: sessions, events: &events}, nil
+	}, RequestScope)
+
+	h := app.Action((*ScopedController).Index)
+	for i := 1; i <= 2; i++ {
+		rw := httptest.NewRecorder()
+		h.ServeHTTP(rw, httptest.NewRequest("GET", "/", nil))
+		equals(t, http.StatusOK, rw.Code)
+		equals(t, fmt.Sprintf("test %d", i), rw.Body.String())
+	}
+	equals(t, 1, configs)
+	equals(t, []string{"destroy 1", "close 1", "destroy 2", "close 2"}, events)
+
+	rw := httptest.NewRecorder()
+	h.ServeHTTP(rw, httptest.NewRequest("GET", "/?fail=1", nil))
+	equals(t, http.StatusInternalServerError, rw.Code)
+
+	_, err := app.Resolve(reflect.TypeOf(&session{}))
+	assert(t, err != nil, "expected request scoped values not to be resolved without a request")
+}
+
+func TestProvideFuncInvalid(t *testing.T) {
+	for _, constructor := range []interface{}{
+		&injectConfig{},
+		func(r *http.Request) *injectConfig { return nil },
+		func() (*injectConfig, string) { return nil, "" },
+	} {
+		func() {
+			defer func() {
+				assert(t, recover() != nil, "expected ProvideFunc to panic for %T", constructor)
+			}()
+			(&App{}).ProvideFunc(constructor, Singleton)
+		}()
+	}
+}
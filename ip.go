@@ -0,0 +1,123 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// TrustedProxies are the address ranges of the reverse proxies in front of
+// the application. The X-Forwarded-For and X-Forwarded-Proto headers are
+// only believed for requests received from a trusted proxy, so clients can
+// not spoof their address or scheme:
+//
+//	controller.TrustedProxies = []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}
+//
+// No proxy is trusted by default.
+var TrustedProxies []netip.Prefix
+
+// ClientIP returns the IP address of the client of the request. For requests
+// forwarded by TrustedProxies, it is the last address of the X-Forwarded-For
+// header that is not a trusted proxy. It returns an invalid address if
+// there is no request, or if the header contains a malformed address, since
+// the client could then hide behind an address of the proxies.
+func (b *Base) ClientIP() netip.Addr {
+	if b.Request == nil {
+		return netip.Addr{}
+	}
+	return clientIP(b.Request)
+}
+
+// clientIP returns the IP address of the client of r.
+func clientIP(r *http.Request) netip.Addr {
+	addr, err := netip.ParseAddrPort(r.RemoteAddr)
+	if err != nil {
+		ip, _ := netip.ParseAddr(r.RemoteAddr)
+		return ip.Unmap()
+	}
+	ip := addr.Addr().Unmap()
+	if !trusted(ip) {
+		return ip
+	}
+	forwarded := r.Header.Values("X-Forwarded-For")
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		hops := strings.Split(forwarded[i], ",")
+		for j := len(hops) - 1; j >= 0; j-- {
+			hop, err := netip.ParseAddr(strings.TrimSpace(hops[j]))
+			if err != nil {
+				return netip.Addr{}
+			}
+			ip = hop.Unmap()
+			if !trusted(ip) {
+				return ip
+			}
+		}
+	}
+	return ip
+}
+
+// trusted reports whether ip is the address of a trusted proxy.
+func trusted(ip netip.Addr) bool {
+	for _, prefix := range TrustedProxies {
+		if prefix.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowIPs returns a filter restricting actions to clients whose address, as
+// returned by Base.ClientIP, is in one of ranges, such as "10.0.0.0/8" or
+// "192.0.2.1". Other requests fail with an HTTPError with code 403:
+//
+//	func (*AdminController) ActionFilters() []controller.Filter {
+//		return []controller.Filter{controller.AllowIPs("10.0.0.0/8", "fd00::/8")}
+//	}
+//
+// AllowIPs panics if a range is invalid.
+func AllowIPs(ranges ...string) Filter {
+	return ipFilter(parseRanges(ranges), true)
+}
+
+// DenyIPs returns a filter rejecting requests from clients whose address is
+// in one of ranges with an HTTPError with code 403, as the opposite of
+// AllowIPs. Clients without a valid address are rejected as well. DenyIPs
+// panics if a range is invalid.
+func DenyIPs(ranges ...string) Filter {
+	return ipFilter(parseRanges(ranges), false)
+}
+
+func ipFilter(prefixes []netip.Prefix, allow bool) Filter {
+	return func(c Controller, rw http.ResponseWriter, r *http.Request) error {
+		ip := clientIP(r)
+		matched := false
+		for _, prefix := range prefixes {
+			if prefix.Contains(ip) {
+				matched = true
+				break
+			}
+		}
+		if matched != allow || !ip.IsValid() {
+			return &HTTPError{Code: http.StatusForbidden, Err: fmt.Errorf("Access denied for %s", ip)}
+		}
+		return nil
+	}
+}
+
+// parseRanges parses address ranges in CIDR notation, or single addresses.
+func parseRanges(ranges []string) []netip.Prefix {
+	prefixes := make([]netip.Prefix, len(ranges))
+	for i, s := range ranges {
+		prefix, err := netip.ParsePrefix(s)
+		if err != nil {
+			ip, ipErr := netip.ParseAddr(s)
+			if ipErr != nil {
+				panic(fmt.Sprintf("controller: invalid IP range %q", s))
+			}
+			prefix = netip.PrefixFrom(ip, ip.BitLen())
+		}
+		prefixes[i] = prefix.Masked()
+	}
+	return prefixes
+}
@@ -0,0 +1,86 @@
+package controller
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+)
+
+func TestClientIP(t *testing.T) {
+	defer func() { TrustedProxies = nil }()
+
+	var tests = []struct {
+		trusted   []netip.Prefix
+		remote    string
+		forwarded []string
+		ip        string
+	}{
+		{nil, "192.0.2.1:1234", nil, "192.0.2.1"},
+		{nil, "10.0.0.1:1234", []string{"192.0.2.1"}, "10.0.0.1"},
+		{nil, "[::ffff:192.0.2.1]:1234", nil, "192.0.2.1"},
+		{[]netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}, "10.0.0.1:1234", []string{"192.0.2.1"}, "192.0.2.1"},
+		{[]netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}, "10.0.0.1:1234", []string{"203.0.113.9, 192.0.2.1, 10.0.0.2"}, "192.0.2.1"},
+		{[]netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}, "10.0.0.1:1234", []string{"192.0.2.1", "10.0.0.2"}, "192.0.2.1"},
+		{[]netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}, "10.0.0.1:1234", []string{"10.0.0.3"}, "10.0.0.3"},
+		{[]netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}, "10.0.0.1:1234", []string{"garbage"}, "invalid IP"},
+		{[]netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}, "10.0.0.1:1234", []string{"192.0.2.1, garbage, 10.0.0.2"}, "invalid IP"},
+		{[]netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}, "192.0.2.1:1234", []string{"203.0.113.9"}, "192.0.2.1"},
+	}
+
+	for _, test := range tests {
+		TrustedProxies = test.trusted
+		r := httptest.NewRequest("GET", "/", nil)
+		r.RemoteAddr = test.remote
+		for _, f := range test.forwarded {
+			r.Header.Add("X-Forwarded-For", f)
+		}
+		equals(t, test.ip, (&Base{Request: r}).ClientIP().String())
+	}
+}
+
+type IPController struct {
+	Base
+}
+
+func (c *IPController) Index() error {
+	return c.Text(http.StatusOK, "ok")
+}
+
+func TestIPFilters(t *testing.T) {
+	TrustedProxies = []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}
+	defer func() { TrustedProxies = nil }()
+	allow := Action((*IPController).Index, UseFilters(AllowIPs("10.0.0.0/8", "2001:db8::1")))
+	deny := Action((*IPController).Index, UseFilters(DenyIPs("192.0.2.0/24")))
+
+	var tests = []struct {
+		h         http.Handler
+		remote    string
+		forwarded string
+		code      int
+	}{
+		{allow, "10.1.2.3:1234", "", http.StatusOK},
+		{allow, "[2001:db8::1]:1234", "", http.StatusOK},
+		{allow, "[2001:db8::2]:1234", "", http.StatusForbidden},
+		{allow, "192.0.2.1:1234", "", http.StatusForbidden},
+		{deny, "192.0.2.1:1234", "", http.StatusForbidden},
+		{deny, "10.1.2.3:1234", "", http.StatusOK},
+		{deny, "10.0.0.1:1234", "192.0.2.1, garbage", http.StatusForbidden},
+	}
+
+	for _, test := range tests {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.RemoteAddr = test.remote
+		if test.forwarded != "" {
+			r.Header.Set("X-Forwarded-For", test.forwarded)
+		}
+		rw := httptest.NewRecorder()
+		test.h.ServeHTTP(rw, r)
+		equals(t, test.code, rw.Code)
+	}
+
+	defer func() {
+		assert(t, recover() != nil, "expected AllowIPs to panic for an invalid range\n")
+	}()
+	AllowIPs("10.0.0.0/33")
+}
@@ -0,0 +1,101 @@
+// Package jwt provides a controller base that authenticates requests with
+// JSON Web Tokens sent as bearer tokens.
+//
+// Controllers embed Controller in place of controller.Base and read the
+// claims of the token from Claims:
+//
+//	type AccountController struct {
+//		jwt.Controller
+//	}
+//
+//	func (c *AccountController) Show() error {
+//		return c.JSON(http.StatusOK, map[string]interface{}{"user": c.Claims["sub"]})
+//	}
+//
+//	controller.Provide(&jwt.Verifier{
+//		Keyfunc: func(*gojwt.Token) (interface{}, error) { return secret, nil },
+//		Options: []gojwt.ParserOption{gojwt.WithValidMethods([]string{"HS256"})},
+//	})
+//	http.Handle("GET /account", controller.Action((*AccountController).Show))
+//
+// Requests without a valid token are answered with 401 Unauthorized through
+// the Error method of the controller, and the action is not invoked.
+package jwt
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	gojwt "github.com/golang-jwt/jwt/v5"
+
+	"github.com/codegangsta/controller"
+)
+
+// Verifier verifies the tokens of requests.
+type Verifier struct {
+	// Keyfunc returns the key to verify the signature of a token with.
+	Keyfunc gojwt.Keyfunc
+	// Options are passed to the parser, for example to restrict the signing
+	// methods accepted or to require an issuer or audience.
+	Options []gojwt.ParserOption
+}
+
+// Controller is a controller.Base that validates the bearer token of the
+// request in Init. Verifier is injected with the *Verifier registered with
+// controller.Provide or provided to the App of the action.
+//
+// Controllers that implement Init themselves must call the method of
+// Controller.
+type Controller struct {
+	controller.Base
+
+	Verifier *Verifier `inject:""`
+	// Token is the validated token of the request.
+	Token *gojwt.Token
+	// Claims are the claims of Token. They are also the principal of the
+	// request, as returned by Base.Principal.
+	Claims gojwt.MapClaims
+}
+
+// Init initializes the base controller and validates the token of the
+// request. Missing and invalid tokens fail with an HTTPError with code 401,
+// carrying a WWW-Authenticate header.
+func (c *Controller) Init(rw http.ResponseWriter, r *http.Request) error {
+	if err := c.Base.Init(rw, r); err != nil {
+		return err
+	}
+	if c.Verifier == nil {
+		return errors.New("No verifier to validate the token with")
+	}
+	raw, ok := bearerToken(r)
+	if !ok {
+		return &controller.HTTPError{
+			Code:   http.StatusUnauthorized,
+			Err:    errors.New("Missing bearer token"),
+			Header: http.Header{"Www-Authenticate": {"Bearer"}},
+		}
+	}
+	claims := gojwt.MapClaims{}
+	token, err := gojwt.ParseWithClaims(raw, claims, c.Verifier.Keyfunc, c.Verifier.Options...)
+	if err != nil {
+		return &controller.HTTPError{
+			Code:   http.StatusUnauthorized,
+			Err:    err,
+			Header: http.Header{"Www-Authenticate": {`Bearer error="invalid_token"`}},
+		}
+	}
+	c.Token, c.Claims = token, claims
+	c.Request = r.WithContext(controller.WithPrincipal(r.Context(), claims))
+	return nil
+}
+
+// bearerToken returns the bearer token of the Authorization header of r.
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
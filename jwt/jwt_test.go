@@ -0,0 +1,89 @@
+package jwt
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	gojwt "github.com/golang-jwt/jwt/v5"
+
+	"github.com/codegangsta/controller"
+)
+
+var secret = []byte("secret")
+
+type AccountController struct {
+	Controller
+}
+
+func (c *AccountController) Show() error {
+	return c.Text(http.StatusOK, c.Claims["sub"].(string))
+}
+
+func sign(t *testing.T, claims gojwt.MapClaims, key []byte) string {
+	token, err := gojwt.NewWithClaims(gojwt.SigningMethodHS256, claims).SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func TestController(t *testing.T) {
+	app := &controller.App{}
+	app.Provide(&Verifier{
+		Keyfunc: func(*gojwt.Token) (interface{}, error) { return secret, nil },
+		Options: []gojwt.ParserOption{gojwt.WithValidMethods([]string{"HS256"})},
+	})
+	h := app.Action((*AccountController).Show)
+
+	var tests = []struct {
+		auth string
+		code int
+		body string
+	}{
+		{"", http.StatusUnauthorized, "Missing bearer token\n"},
+		{"Basic dXNlcjpwYXNz", http.StatusUnauthorized, "Missing bearer token\n"},
+		{"Bearer " + sign(t, gojwt.MapClaims{"sub": "gopher"}, []byte("other")), http.StatusUnauthorized, ""},
+		{"Bearer " + sign(t, gojwt.MapClaims{"sub": "gopher", "exp": time.Now().Add(-time.Hour).Unix()}, secret), http.StatusUnauthorized, ""},
+		{"Bearer " + sign(t, gojwt.MapClaims{"sub": "gopher", "exp": time.Now().Add(time.Hour).Unix()}, secret), http.StatusOK, "gopher"},
+	}
+
+	for _, test := range tests {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("Authorization", test.auth)
+		rw := httptest.NewRecorder()
+		h.ServeHTTP(rw, r)
+		if rw.Code != test.code {
+			t.Errorf("Expected %d for %q, got %d", test.code, test.auth, rw.Code)
+		}
+		if test.body != "" && rw.Body.String() != test.body {
+			t.Errorf("Expected body %q, got %q", test.body, rw.Body.String())
+		}
+		if rw.Code == http.StatusUnauthorized && rw.Header().Get("WWW-Authenticate") == "" {
+			t.Errorf("Expected a WWW-Authenticate header for %q", test.auth)
+		}
+	}
+}
+
+type PrincipalController struct {
+	Controller
+}
+
+func (c *PrincipalController) Show() error {
+	claims, _ := c.Principal().(gojwt.MapClaims)
+	return c.Text(http.StatusOK, claims["sub"].(string))
+}
+
+func TestPrincipal(t *testing.T) {
+	app := &controller.App{}
+	app.Provide(&Verifier{Keyfunc: func(*gojwt.Token) (interface{}, error) { return secret, nil }})
+
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("Authorization", "Bearer "+sign(t, gojwt.MapClaims{"sub": "gopher"}, secret))
+	rw := httptest.NewRecorder()
+	app.Action((*PrincipalController).Show).ServeHTTP(rw, r)
+	if rw.Body.String() != "gopher" {
+		t.Errorf("Expected the claims to be the principal, got %q", rw.Body.String())
+	}
+}
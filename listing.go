@@ -0,0 +1,83 @@
+package controller
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SortField is a field a collection is sorted by.
+type SortField struct {
+	Field string
+	Desc  bool
+}
+
+// String returns the field in the notation of the sort parameter, with a
+// leading minus for descending order.
+func (s SortField) String() string {
+	if s.Desc {
+		return "-" + s.Field
+	}
+	return s.Field
+}
+
+// Sort parses the sort query parameter, a comma separated list of fields
+// prefixed with a minus for descending order:
+//
+//	GET /users?sort=-created_at,name
+//
+// Only the fields given in allowed may be sorted by, others fail with an
+// HTTPError with code 400. Sort returns nil if the parameter is not present.
+func (b *Base) Sort(allowed ...string) ([]SortField, error) {
+	param := b.Request.URL.Query().Get("sort")
+	if param == "" {
+		return nil, nil
+	}
+
+	var fields []SortField
+	for _, part := range strings.Split(param, ",") {
+		part = strings.TrimSpace(part)
+		field := SortField{Field: strings.TrimPrefix(part, "-"), Desc: strings.HasPrefix(part, "-")}
+		if !contains(allowed, field.Field) {
+			return nil, invalidParam("sort", fmt.Sprintf("can not sort by %q", field.Field))
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+// Filters parses the filter query parameters, which name the filtered field
+// in brackets and may list several comma separated values:
+//
+//	GET /users?filter[status]=active,invited&filter[role]=admin
+//
+// The values are returned by field. Only the fields given in allowed may be
+// filtered by, others fail with an HTTPError with code 400.
+func (b *Base) Filters(allowed ...string) (map[string][]string, error) {
+	filters := make(map[string][]string)
+	for key, values := range b.Request.URL.Query() {
+		if !strings.HasPrefix(key, "filter[") || !strings.HasSuffix(key, "]") {
+			continue
+		}
+		field := key[len("filter[") : len(key)-1]
+		if !contains(allowed, field) {
+			return nil, invalidParam(key, fmt.Sprintf("can not filter by %q", field))
+		}
+		for _, value := range values {
+			for _, v := range strings.Split(value, ",") {
+				if v = strings.TrimSpace(v); v != "" {
+					filters[field] = append(filters[field], v)
+				}
+			}
+		}
+	}
+	return filters, nil
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
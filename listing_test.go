@@ -0,0 +1,34 @@
+package controller
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSort(t *testing.T) {
+	c := &Base{Request: httptest.NewRequest("GET", "/?sort=-created_at,name", nil)}
+	fields, err := c.Sort("name", "created_at")
+	ok(t, err)
+	equals(t, []SortField{{"created_at", true}, {"name", false}}, fields)
+	equals(t, "-created_at", fields[0].String())
+
+	_, err = c.Sort("name")
+	equals(t, http.StatusBadRequest, errorCode(err))
+	equals(t, `Parameter "sort" can not sort by "created_at"`, err.Error())
+
+	c = &Base{Request: httptest.NewRequest("GET", "/", nil)}
+	fields, err = c.Sort("name")
+	ok(t, err)
+	equals(t, []SortField(nil), fields)
+}
+
+func TestFilters(t *testing.T) {
+	c := &Base{Request: httptest.NewRequest("GET", "/?filter[status]=active,invited&filter[role]=admin&page=2", nil)}
+	filters, err := c.Filters("status", "role")
+	ok(t, err)
+	equals(t, map[string][]string{"status": {"active", "invited"}, "role": {"admin"}}, filters)
+
+	_, err = c.Filters("status")
+	equals(t, http.StatusBadRequest, errorCode(err))
+}
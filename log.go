@@ -0,0 +1,42 @@
+package controller
+
+import (
+	"log/slog"
+	"net/http"
+)
+
+// RequestIDHeader is the request header the ID of a request is read from,
+// as set by load balancers and request ID middleware.
+var RequestIDHeader = "X-Request-Id"
+
+// NewLogger creates the logger of a request. The default logger is
+// slog.Default() with the method, path and ID of the request (see
+// Base.RequestID) attached, so that the lines logged by an action can be told
+// apart from those of other requests. Applications can replace it to log to
+// another handler or attach more attributes:
+//
+//	controller.NewLogger = func(r *http.Request) *slog.Logger {
+//		return logger.With("method", r.Method, "path", r.URL.Path, "ip", r.RemoteAddr)
+//	}
+var NewLogger = func(r *http.Request) *slog.Logger {
+	if id := requestID(r); id != "" {
+		return slog.Default().With("method", r.Method, "path", r.URL.Path, "request_id", id)
+	}
+	return slog.Default().With("method", r.Method, "path", r.URL.Path)
+}
+
+// Log returns the logger of the request, creating it with NewLogger on first
+// use and keeping it in Logger:
+//
+//	c.Log().Info("user created", "id", user.ID)
+//
+// Init can set Logger to use another logger for a controller.
+func (b *Base) Log() *slog.Logger {
+	if b.Logger == nil {
+		if b.Request == nil {
+			return slog.Default()
+		}
+		b.Logger = NewLogger(b.Request)
+	}
+	return b.Logger
+}
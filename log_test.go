@@ -0,0 +1,41 @@
+package controller
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type LogController struct {
+	Base
+}
+
+func (c *LogController) Index() error {
+	c.Log().Info("listing users")
+	return nil
+}
+
+func TestLog(t *testing.T) {
+	var buf bytes.Buffer
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(slog.New(slog.NewTextHandler(&buf, nil)))
+
+	r := httptest.NewRequest("GET", "/users", nil)
+	r.Header.Set("X-Request-Id", "abc")
+	Action((*LogController).Index).ServeHTTP(httptest.NewRecorder(), r)
+	line := buf.String()
+	for _, attr := range []string{"msg=\"listing users\"", "method=GET", "path=/users", "request_id=abc"} {
+		assert(t, strings.Contains(line, attr), "expected %s in %q", attr, line)
+	}
+
+	buf.Reset()
+	defer func(newLogger func(*http.Request) *slog.Logger) { NewLogger = newLogger }(NewLogger)
+	NewLogger = func(r *http.Request) *slog.Logger {
+		return slog.Default().With("ip", r.RemoteAddr)
+	}
+	Action((*LogController).Index).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	assert(t, strings.Contains(buf.String(), "ip=192.0.2.1:1234"), "expected the custom logger, got %q", buf.String())
+}
@@ -0,0 +1,124 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+)
+
+// MiddlewareProvider is implemented by controllers that declare middleware
+// wrapping all of their actions, so that cross-cutting concerns such as
+// authentication or compression are configured once per controller instead
+// of once per route:
+//
+//	func (*AdminController) Middleware() []func(http.Handler) http.Handler {
+//		return []func(http.Handler) http.Handler{requireAdmin, gziphandler.GzipHandler}
+//	}
+//
+// Middleware is called once on a zero value of the controller when an
+// action is created with Action, ActionOf, ActionWith or Handler. The first
+// middleware is the outermost one. It runs before the controller is
+// constructed, so it can reject requests before Init is called.
+type MiddlewareProvider interface {
+	Middleware() []func(http.Handler) http.Handler
+}
+
+// Use wraps a single action in middleware, for concerns such as rate limits
+// or caching that only apply to some endpoints:
+//
+//	controller.Action((*SearchController).Index, controller.Use(rateLimit, cache(time.Minute)))
+//
+// The first middleware is the outermost one. Middleware declared by the
+// controller with MiddlewareProvider wraps the middleware of the action.
+func Use(middleware ...func(http.Handler) http.Handler) Option {
+	return func(o *options) {
+		o.middleware = append(o.middleware, middleware...)
+	}
+}
+
+// withMiddleware wraps h in the middleware of the action, then in the
+// middleware declared by c if it is a MiddlewareProvider.
+func withMiddleware(h http.Handler, c Controller, o *options) http.Handler {
+	h = chain(h, o.middleware)
+	if p, ok := c.(MiddlewareProvider); ok {
+		return chain(h, p.Middleware())
+	}
+	return h
+}
+
+// chain wraps h in middleware, the first of which is the outermost.
+func chain(h http.Handler, middleware []func(http.Handler) http.Handler) http.Handler {
+	for i := len(middleware) - 1; i >= 0; i-- {
+		h = middleware[i](h)
+	}
+	return h
+}
+
+// Wrap returns the handler of action wrapped in standard net/http
+// middleware, which runs outside the lifecycle of the controller. It is a
+// shorthand for Action with the Use option:
+//
+//	mux.Handle("GET /account", controller.Wrap((*AccountController).Show, requestID, authenticate))
+//
+// Middleware hands values to controllers through the request context. The
+// request ID and authenticated principal have dedicated accessors on Base,
+// for middleware that stores them with WithRequestID and WithPrincipal.
+func Wrap(action interface{}, middleware ...func(http.Handler) http.Handler) http.Handler {
+	return Action(action, Use(middleware...))
+}
+
+type (
+	requestIDKey struct{}
+	principalKey struct{}
+)
+
+// WithRequestID returns a copy of ctx carrying the ID of the request, for
+// middleware assigning request IDs.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// WithPrincipal returns a copy of ctx carrying the authenticated principal
+// of the request, such as a user or an API client, for authentication
+// middleware.
+func WithPrincipal(ctx context.Context, principal interface{}) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// RequestID returns the ID of the request, as set by middleware with
+// WithRequestID, or else sent in the RequestIDHeader header. It is empty if
+// the request has no ID.
+func (b *Base) RequestID() string {
+	if b.Request == nil {
+		return ""
+	}
+	return requestID(b.Request)
+}
+
+// requestID returns the ID of r.
+func requestID(r *http.Request) string {
+	if id, ok := r.Context().Value(requestIDKey{}).(string); ok {
+		return id
+	}
+	return r.Header.Get(RequestIDHeader)
+}
+
+// Principal returns the authenticated principal of the request as set by
+// middleware with WithPrincipal, or nil if there is none.
+func (b *Base) Principal() interface{} {
+	return b.Context().Value(principalKey{})
+}
+
+// Chain is a chain of middleware, such as an alice.Chain.
+type Chain interface {
+	// Then returns h wrapped in the middleware of the chain.
+	Then(h http.Handler) http.Handler
+}
+
+// ActionChain returns the handler of action with chain applied, making per
+// route middleware composition a single call:
+//
+//	auth := alice.New(logging, authenticate)
+//	mux.Handle("GET /account", controller.ActionChain(auth, (*AccountController).Show))
+func ActionChain(chain Chain, action interface{}, opts ...Option) http.Handler {
+	return chain.Then(Action(action, opts...))
+}
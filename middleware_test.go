@@ -0,0 +1,89 @@
+package controller
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// tag returns a middleware appending name to the X-Middleware header.
+func tag(name string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			rw.Header().Add("X-Middleware", name)
+			next.ServeHTTP(rw, r)
+		})
+	}
+}
+
+type MiddlewareController struct {
+	Base
+}
+
+func (*MiddlewareController) Middleware() []func(http.Handler) http.Handler {
+	return []func(http.Handler) http.Handler{tag("outer"), tag("inner")}
+}
+
+func (c *MiddlewareController) Index() error {
+	return c.Text(http.StatusOK, "index")
+}
+
+func TestControllerMiddleware(t *testing.T) {
+	for _, h := range []http.Handler{
+		Action((*MiddlewareController).Index),
+		ActionOf((*MiddlewareController).Index),
+	} {
+		rw := httptest.NewRecorder()
+		h.ServeHTTP(rw, httptest.NewRequest("GET", "/", nil))
+		equals(t, "index", rw.Body.String())
+		equals(t, []string{"outer", "inner"}, rw.Header()["X-Middleware"])
+	}
+}
+
+func TestUse(t *testing.T) {
+	rw := httptest.NewRecorder()
+	Action((*MiddlewareController).Index, Use(tag("action 1"), tag("action 2"))).ServeHTTP(rw, httptest.NewRequest("GET", "/", nil))
+	equals(t, "index", rw.Body.String())
+	equals(t, []string{"outer", "inner", "action 1", "action 2"}, rw.Header()["X-Middleware"])
+}
+
+type PrincipalController struct {
+	Base
+}
+
+func (c *PrincipalController) Show() error {
+	return c.Textf(http.StatusOK, "%v %s", c.Principal(), c.RequestID())
+}
+
+func TestWrap(t *testing.T) {
+	authenticate := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			ctx := WithRequestID(r.Context(), "req-1")
+			next.ServeHTTP(rw, r.WithContext(WithPrincipal(ctx, "gopher")))
+		})
+	}
+	rw := httptest.NewRecorder()
+	Wrap((*PrincipalController).Show, tag("logging"), authenticate).ServeHTTP(rw, httptest.NewRequest("GET", "/", nil))
+	equals(t, "gopher req-1", rw.Body.String())
+	equals(t, "logging", rw.Header().Get("X-Middleware"))
+
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("X-Request-Id", "req-2")
+	rw = httptest.NewRecorder()
+	Action((*PrincipalController).Show).ServeHTTP(rw, r)
+	equals(t, "<nil> req-2", rw.Body.String())
+}
+
+// testChain mirrors alice.Chain.
+type testChain []func(http.Handler) http.Handler
+
+func (c testChain) Then(h http.Handler) http.Handler {
+	return chain(h, c)
+}
+
+func TestActionChain(t *testing.T) {
+	rw := httptest.NewRecorder()
+	ActionChain(testChain{tag("first"), tag("second")}, (*MiddlewareController).Index).ServeHTTP(rw, httptest.NewRequest("GET", "/", nil))
+	equals(t, "index", rw.Body.String())
+	equals(t, []string{"first", "second", "outer", "inner"}, rw.Header()["X-Middleware"])
+}
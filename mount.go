@@ -0,0 +1,350 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Registrar is handed to the Routes method of a Routable controller so the
+// controller can declare the routes it serves.
+type Registrar interface {
+	// Handle routes requests with the given method and path to action, a method
+	// expression as accepted by Action. An empty method matches every method.
+	Handle(method, path string, action interface{})
+}
+
+// Routable is an optional interface for controllers that declare their own
+// routes. This keeps the routing of a controller next to its actions:
+//
+//	func (c *UserController) Routes(r controller.Registrar) {
+//		r.Handle("GET", "/users", (*UserController).Index)
+//		r.Handle("GET", "/users/{id:int}", (*UserController).Show)
+//	}
+//
+// Path wildcards may carry a constraint after a colon, either a regular
+// expression ("{id:[0-9]+}") or one of the shorthands in Constraints
+// ("{id:int}"). Requests whose parameters do not satisfy the constraints are
+// answered with 404 Not Found before the controller is constructed.
+type Routable interface {
+	Controller
+	Routes(r Registrar)
+}
+
+// Constraints holds the shorthand names that can be used as path parameter
+// constraints, mapped to the regular expression they stand for.
+var Constraints = map[string]string{
+	"int":   `-?[0-9]+`,
+	"uint":  `[0-9]+`,
+	"alpha": `[a-zA-Z]+`,
+	"alnum": `[a-zA-Z0-9]+`,
+	"slug":  `[a-z0-9]+(?:-[a-z0-9]+)*`,
+	"uuid":  `[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`,
+}
+
+// Mux is the interface a router has to satisfy for Mount. It is implemented
+// by http.ServeMux and Router.
+type Mux interface {
+	Handle(pattern string, handler http.Handler)
+}
+
+// Mount registers the routes declared by c on mux, with every path prefixed
+// by prefix. Routes are registered with method qualified patterns such as
+// "GET /api/users/{id}", as understood by http.ServeMux.
+//
+// OPTIONS requests are answered automatically for every path the controller
+// declares, listing the methods routed for that path in the Allow header,
+// unless the controller routes OPTIONS for the path itself. The automatic
+// handlers are wrapped in the middleware the controller declares with
+// MiddlewareProvider, so that CORS.Handler answers preflight requests.
+// Requests using a method that is not routed for a declared path are
+// answered by http.ServeMux with 405 Method Not Allowed and the same Allow
+// header. Controllers mounted on the same mux share the automatic OPTIONS
+// handlers of the paths they have in common, which list the methods routed
+// by all of them. Preflight requests are handled with the middleware of the
+// controller routing the requested method, other OPTIONS requests without
+// middleware.
+//
+// c may be a nil pointer, in which case a zero controller is instantiated to
+// declare the routes.
+func Mount(mux Mux, prefix string, c Routable) {
+	if v := reflect.ValueOf(c); v.Kind() == reflect.Ptr && v.IsNil() {
+		c = reflect.New(v.Type().Elem()).Interface().(Routable)
+	}
+	r := &registrar{mux: mux, prefix: strings.TrimSuffix(prefix, "/"), c: c}
+	c.Routes(r)
+	r.options()
+}
+
+type registrar struct {
+	mux     Mux
+	prefix  string
+	c       Controller
+	paths   []string
+	methods map[string][]string
+}
+
+func (r *registrar) Handle(method, path string, action interface{}) {
+	if router, ok := r.mux.(*Router); ok {
+		val := reflect.ValueOf(action)
+		t, err := controllerType(val)
+		if err != nil {
+			panic(err)
+		}
+		router.addRoute(Route{
+			Method:     method,
+			Path:       r.prefix + path,
+			Controller: reflect.PtrTo(t),
+			Action:     actionName(val),
+			Params:     paramType(val.Type()),
+		})
+	}
+
+	path, constraints, err := parsePath(path)
+	if err != nil {
+		panic(err)
+	}
+
+	pattern := r.prefix + path
+	r.track(method, pattern)
+	if method != "" {
+		pattern = method + " " + pattern
+	}
+
+	h := Action(action)
+	if len(constraints) > 0 {
+		h = constrain(h, constraints)
+	}
+	r.mux.Handle(pattern, h)
+}
+
+// track records that path is routed for method.
+func (r *registrar) track(method, path string) {
+	if r.methods == nil {
+		r.methods = make(map[string][]string)
+	}
+	if _, ok := r.methods[path]; !ok {
+		r.paths = append(r.paths, path)
+	}
+	r.methods[path] = append(r.methods[path], method)
+}
+
+// handlerMux is implemented by muxes that report the handler registered for
+// a request, such as http.ServeMux and Router.
+type handlerMux interface {
+	Handler(r *http.Request) (h http.Handler, pattern string)
+}
+
+// mountMu serializes the updates of automatic OPTIONS handlers shared by
+// controllers mounted on the same mux.
+var mountMu sync.Mutex
+
+// options registers the automatic OPTIONS handlers for the tracked paths, or
+// adds the methods of the controller to the handlers registered by
+// controllers mounted before.
+func (r *registrar) options() {
+	mountMu.Lock()
+	defer mountMu.Unlock()
+
+	for _, path := range r.paths {
+		var allow []string
+		explicit := false
+		for _, method := range r.methods[path] {
+			switch method {
+			case "", http.MethodOptions:
+				explicit = true
+			case http.MethodGet:
+				allow = append(allow, http.MethodGet, http.MethodHead)
+			default:
+				allow = append(allow, method)
+			}
+		}
+		if explicit {
+			continue
+		}
+		pattern := http.MethodOptions + " " + path
+		if h := r.registered(pattern); h != nil {
+			h.add(allow, r.c)
+			continue
+		}
+		h := &optionsHandler{}
+		h.add(allow, r.c)
+		r.mux.Handle(pattern, h)
+	}
+}
+
+// registered returns the automatic OPTIONS handler registered on the mux for
+// pattern, or nil if there is none or the mux can not report it.
+func (r *registrar) registered(pattern string) *optionsHandler {
+	mux, ok := r.mux.(handlerMux)
+	if !ok {
+		return nil
+	}
+	method, path, _ := strings.Cut(pattern, " ")
+	probe := &http.Request{Method: method, URL: &url.URL{Path: strings.TrimSuffix(path, "{$}")}}
+	h, registered := mux.Handler(probe)
+	if registered != pattern {
+		return nil
+	}
+	oh, _ := h.(*optionsHandler)
+	return oh
+}
+
+// optionsHandler answers requests with an empty response listing the allowed
+// methods. Preflight requests are passed through the middleware of the
+// controller routing the requested method first.
+type optionsHandler struct {
+	routes atomic.Pointer[optionsRoutes]
+}
+
+// optionsRoutes are the allowed methods of an optionsHandler and the
+// handlers answering preflight requests for them. They are replaced as a
+// whole when controllers are mounted.
+type optionsRoutes struct {
+	header   string
+	methods  []string
+	handlers []methodsHandler
+}
+
+// methodsHandler is the handler for preflight requests for a set of methods.
+type methodsHandler struct {
+	methods []string
+	h       http.Handler
+}
+
+// add adds the methods routed by c to the allowed methods. Callers hold
+// mountMu.
+func (h *optionsHandler) add(methods []string, c Controller) {
+	routes := &optionsRoutes{}
+	if old := h.routes.Load(); old != nil {
+		*routes = *old
+	}
+	routes.methods = routes.methods[:len(routes.methods):len(routes.methods)]
+	for _, method := range methods {
+		if !contains(routes.methods, method) {
+			routes.methods = append(routes.methods, method)
+		}
+	}
+	routes.header = strings.Join(append(routes.methods[:len(routes.methods):len(routes.methods)], http.MethodOptions), ", ")
+	routes.handlers = append(routes.handlers[:len(routes.handlers):len(routes.handlers)], methodsHandler{
+		methods: methods,
+		h:       withMiddleware(http.HandlerFunc(h.allow), c, &options{}),
+	})
+	h.routes.Store(routes)
+}
+
+func (h *optionsHandler) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	if method := r.Header.Get("Access-Control-Request-Method"); method != "" {
+		for _, mh := range h.routes.Load().handlers {
+			if contains(mh.methods, method) {
+				mh.h.ServeHTTP(rw, r)
+				return
+			}
+		}
+	}
+	h.allow(rw, r)
+}
+
+// allow answers r with the allowed methods.
+func (h *optionsHandler) allow(rw http.ResponseWriter, r *http.Request) {
+	rw.Header().Set("Allow", h.routes.Load().header)
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+// parsePath strips the constraints from the wildcards in path, returning the
+// plain http.ServeMux pattern along with the compiled constraints by name.
+func parsePath(path string) (string, map[string]*regexp.Regexp, error) {
+	pattern, params, err := splitPath(path)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var constraints map[string]*regexp.Regexp
+	for _, param := range params {
+		if param.constraint == "" {
+			continue
+		}
+		expr := param.constraint
+		if alias, ok := Constraints[expr]; ok {
+			expr = alias
+		}
+		re, err := regexp.Compile(`^(?:` + expr + `)$`)
+		if err != nil {
+			return "", nil, err
+		}
+		if constraints == nil {
+			constraints = make(map[string]*regexp.Regexp)
+		}
+		constraints[param.name] = re
+	}
+	return pattern, constraints, nil
+}
+
+// pathParam is a wildcard of a route path.
+type pathParam struct {
+	name       string
+	constraint string
+}
+
+// splitPath separates the wildcards of path from the rest of it, returning
+// the plain http.ServeMux pattern and the wildcards in order of appearance.
+func splitPath(path string) (string, []pathParam, error) {
+	var (
+		b      strings.Builder
+		params []pathParam
+	)
+
+	for {
+		start := strings.IndexByte(path, '{')
+		if start < 0 {
+			b.WriteString(path)
+			return b.String(), params, nil
+		}
+
+		// Constraints may contain braces themselves, so look for the brace
+		// that balances the opening one.
+		end, depth := -1, 0
+		for i := start; i < len(path) && end < 0; i++ {
+			switch path[i] {
+			case '{':
+				depth++
+			case '}':
+				if depth--; depth == 0 {
+					end = i
+				}
+			}
+		}
+		if end < 0 {
+			return "", nil, fmt.Errorf("Unbalanced braces in route %q", path)
+		}
+
+		name, constraint, _ := strings.Cut(path[start+1:end], ":")
+		b.WriteString(path[:start+1])
+		b.WriteString(name)
+		b.WriteByte('}')
+		path = path[end+1:]
+
+		if name != "$" {
+			params = append(params, pathParam{strings.TrimSuffix(name, "..."), constraint})
+		}
+	}
+}
+
+// constrain answers requests with 404 Not Found unless their path values
+// satisfy the given constraints.
+func constrain(h http.Handler, constraints map[string]*regexp.Regexp) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		for name, re := range constraints {
+			if !re.MatchString(r.PathValue(name)) {
+				http.NotFound(rw, r)
+				return
+			}
+		}
+		h.ServeHTTP(rw, r)
+	})
+}
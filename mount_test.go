@@ -0,0 +1,184 @@
+package controller
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type RoutesController struct {
+	Base
+}
+
+func (c *RoutesController) Routes(r Registrar) {
+	r.Handle("GET", "/users/{id:int}", (*RoutesController).Show)
+	r.Handle("GET", "/codes/{id:[A-Z]{3}}", (*RoutesController).Show)
+	r.Handle("", "/ping", (*RoutesController).Ping)
+	r.Handle("POST", "/users", (*RoutesController).Create)
+}
+
+func (c *RoutesController) Show() error {
+	c.ResponseWriter.Write([]byte("user " + c.Request.PathValue("id")))
+	return nil
+}
+
+func (c *RoutesController) Create(in *TestParams) error {
+	c.ResponseWriter.Write([]byte("created " + in.Name))
+	return nil
+}
+
+func (c *RoutesController) Ping() error {
+	c.ResponseWriter.Write([]byte("pong"))
+	return nil
+}
+
+func TestMount(t *testing.T) {
+	mux := http.NewServeMux()
+	Mount(mux, "/api/", (*RoutesController)(nil))
+
+	var tests = []struct {
+		method string
+		path   string
+		code   int
+		body   string
+	}{
+		{"GET", "/api/users/1", http.StatusOK, "user 1"},
+		{"POST", "/api/users/1", http.StatusMethodNotAllowed, ""},
+		{"POST", "/api/ping", http.StatusOK, "pong"},
+		{"GET", "/users/1", http.StatusNotFound, ""},
+		{"GET", "/api/users/abc", http.StatusNotFound, ""},
+		{"GET", "/api/codes/ABC", http.StatusOK, "user ABC"},
+		{"GET", "/api/codes/ABCD", http.StatusNotFound, ""},
+		{"OPTIONS", "/api/users/1", http.StatusNoContent, ""},
+	}
+
+	for _, test := range tests {
+		rw := httptest.NewRecorder()
+		mux.ServeHTTP(rw, httptest.NewRequest(test.method, test.path, nil))
+		equals(t, test.code, rw.Code)
+		if test.body != "" {
+			equals(t, test.body, rw.Body.String())
+		}
+	}
+}
+
+func TestMountMethodNotAllowed(t *testing.T) {
+	mux := http.NewServeMux()
+	Mount(mux, "/api", (*RoutesController)(nil))
+
+	rw := httptest.NewRecorder()
+	mux.ServeHTTP(rw, httptest.NewRequest("DELETE", "/api/users/1", nil))
+	equals(t, http.StatusMethodNotAllowed, rw.Code)
+	equals(t, "GET, HEAD, OPTIONS", rw.Header().Get("Allow"))
+}
+
+func TestMountOptions(t *testing.T) {
+	mux := http.NewServeMux()
+	Mount(mux, "/api", (*RoutesController)(nil))
+
+	rw := httptest.NewRecorder()
+	mux.ServeHTTP(rw, httptest.NewRequest("OPTIONS", "/api/users/1", nil))
+	equals(t, http.StatusNoContent, rw.Code)
+	equals(t, "GET, HEAD, OPTIONS", rw.Header().Get("Allow"))
+
+	// Routes accepting any method handle OPTIONS themselves.
+	rw = httptest.NewRecorder()
+	mux.ServeHTTP(rw, httptest.NewRequest("OPTIONS", "/api/ping", nil))
+	equals(t, http.StatusOK, rw.Code)
+	equals(t, "pong", rw.Body.String())
+}
+
+func TestParsePath(t *testing.T) {
+	var tests = []struct {
+		path    string
+		pattern string
+		names   []string
+	}{
+		{"/users", "/users", nil},
+		{"/users/{id}", "/users/{id}", nil},
+		{"/users/{id:int}/posts/{slug:[a-z]{2,}}", "/users/{id}/posts/{slug}", []string{"id", "slug"}},
+		{"/files/{path...:.*\\.txt}", "/files/{path...}", []string{"path"}},
+	}
+
+	for _, test := range tests {
+		pattern, constraints, err := parsePath(test.path)
+		ok(t, err)
+		equals(t, test.pattern, pattern)
+		equals(t, len(test.names), len(constraints))
+		for _, name := range test.names {
+			assert(t, constraints[name] != nil, "missing constraint %q for %s\n", name, test.path)
+		}
+	}
+
+	_, _, err := parsePath("/users/{id:[0-9]+")
+	assert(t, err != nil, "expected an error for unbalanced braces\n")
+}
+
+type UserReadController struct {
+	Base
+}
+
+func (c *UserReadController) Routes(r Registrar) {
+	r.Handle("GET", "/users", (*UserReadController).Index)
+}
+
+func (c *UserReadController) Index() error {
+	return c.Text(http.StatusOK, "index")
+}
+
+type UserWriteController struct {
+	Base
+}
+
+func (*UserWriteController) Middleware() []func(http.Handler) http.Handler {
+	return []func(http.Handler) http.Handler{func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			rw.Header().Set("X-Controller", "write")
+			next.ServeHTTP(rw, r)
+		})
+	}}
+}
+
+func (c *UserWriteController) Routes(r Registrar) {
+	r.Handle("POST", "/users", (*UserWriteController).Create)
+}
+
+func (c *UserWriteController) Create() error {
+	return c.Text(http.StatusCreated, "created")
+}
+
+func TestMountSharedPath(t *testing.T) {
+	mux := http.NewServeMux()
+	Mount(mux, "/api", (*UserReadController)(nil))
+	Mount(mux, "/api", (*UserWriteController)(nil))
+
+	rw := httptest.NewRecorder()
+	mux.ServeHTTP(rw, httptest.NewRequest("OPTIONS", "/api/users", nil))
+	equals(t, http.StatusNoContent, rw.Code)
+	equals(t, "GET, HEAD, POST, OPTIONS", rw.Header().Get("Allow"))
+	equals(t, "", rw.Header().Get("X-Controller"))
+
+	// Preflight requests get the middleware of the controller routing the
+	// requested method.
+	preflight := func(method string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("OPTIONS", "/api/users", nil)
+		r.Header.Set("Access-Control-Request-Method", method)
+		rw := httptest.NewRecorder()
+		mux.ServeHTTP(rw, r)
+		return rw
+	}
+	equals(t, "write", preflight("POST").Header().Get("X-Controller"))
+	equals(t, "", preflight("GET").Header().Get("X-Controller"))
+	equals(t, "GET, HEAD, POST, OPTIONS", preflight("GET").Header().Get("Allow"))
+
+	rw = httptest.NewRecorder()
+	mux.ServeHTTP(rw, httptest.NewRequest("POST", "/api/users", nil))
+	equals(t, "created", rw.Body.String())
+
+	// Other muxes are not affected.
+	other := http.NewServeMux()
+	Mount(other, "/api", (*UserWriteController)(nil))
+	rw = httptest.NewRecorder()
+	other.ServeHTTP(rw, httptest.NewRequest("OPTIONS", "/api/users", nil))
+	equals(t, "POST, OPTIONS", rw.Header().Get("Allow"))
+}
@@ -0,0 +1,100 @@
+// Package msgpack adds support for MessagePack request and response bodies
+// to controllers.
+//
+// Importing the package registers a binder for the application/msgpack media
+// type, so Base.Bind and the arguments of actions accept MessagePack bodies
+// alongside JSON ones:
+//
+//	import _ "github.com/codegangsta/controller/msgpack"
+//
+//	func (c *EventController) Create(in *Event) error
+//
+// Controllers that render MessagePack embed Controller, which adds the
+// MsgPack and Render methods to controller.Base.
+//
+// Struct fields are matched by their `msgpack` tag, or by their `json` tag
+// if they have none, so types shared with JSON APIs need no extra tags.
+package msgpack
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/codegangsta/controller"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// MediaType is the media type of MessagePack bodies.
+const MediaType = "application/msgpack"
+
+// mediaTypes lists the media types MessagePack bodies are sent with in the
+// wild.
+var mediaTypes = []string{MediaType, "application/x-msgpack", "application/vnd.msgpack"}
+
+func init() {
+	for _, mediaType := range mediaTypes {
+		controller.RegisterBinder(mediaType, controller.BinderFunc(bind))
+	}
+}
+
+func bind(r *http.Request, dst interface{}) error {
+	dec := msgpack.NewDecoder(r.Body)
+	dec.SetCustomStructTag("json")
+	if err := dec.Decode(dst); err != nil {
+		return &controller.HTTPError{Code: http.StatusBadRequest, Err: fmt.Errorf("Malformed MessagePack: %w", err)}
+	}
+	return nil
+}
+
+// Controller is a controller.Base with MessagePack support, meant to be
+// embedded in your own controller struct.
+type Controller struct {
+	controller.Base
+}
+
+// MsgPack writes v encoded as MessagePack with the given status code. If v
+// can not be encoded, nothing is written and the error is returned.
+func (c *Controller) MsgPack(code int, v interface{}) error {
+	var buf bytes.Buffer
+	enc := msgpack.NewEncoder(&buf)
+	enc.SetCustomStructTag("json")
+	if err := enc.Encode(v); err != nil {
+		return err
+	}
+	c.ResponseWriter.Header().Set("Content-Type", MediaType)
+	c.ResponseWriter.WriteHeader(code)
+	_, err := c.ResponseWriter.Write(buf.Bytes())
+	return err
+}
+
+// Render writes v as MessagePack if the request accepts application/msgpack,
+// and as JSON otherwise. This lets a single action serve internal services
+// speaking MessagePack as well as browsers and other JSON clients.
+func (c *Controller) Render(code int, v interface{}) error {
+	if accepts(c.Request) {
+		return c.MsgPack(code, v)
+	}
+	return c.JSON(code, v)
+}
+
+// accepts reports whether the Accept header of r lists one of the
+// MessagePack media types with a non-zero quality.
+func accepts(r *http.Request) bool {
+	for _, header := range r.Header.Values("Accept") {
+		for _, part := range strings.Split(header, ",") {
+			t, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+			if err != nil || params["q"] == "0" {
+				continue
+			}
+			for _, mediaType := range mediaTypes {
+				if t == mediaType {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,82 @@
+package msgpack
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/codegangsta/controller"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+type event struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+type EventController struct {
+	Controller
+}
+
+func (c *EventController) Create(in *event) error {
+	in.Count++
+	return c.Render(http.StatusCreated, in)
+}
+
+func TestMsgPack(t *testing.T) {
+	body, err := msgpack.Marshal(map[string]interface{}{"name": "deploy", "count": 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var tests = []struct {
+		contentType string
+		accept      string
+		body        []byte
+		code        int
+		response    string
+	}{
+		{MediaType, "", body, http.StatusCreated, "application/json; charset=utf-8"},
+		{"application/x-msgpack", MediaType, body, http.StatusCreated, MediaType},
+		{"application/json", "text/html, application/msgpack;q=0.9", []byte(`{"name": "deploy", "count": 1}`), http.StatusCreated, MediaType},
+		{"application/json", "application/msgpack;q=0", []byte(`{"name": "deploy", "count": 1}`), http.StatusCreated, "application/json; charset=utf-8"},
+		{MediaType, "", []byte{0xc1}, http.StatusBadRequest, ""},
+	}
+
+	for _, test := range tests {
+		r := httptest.NewRequest("POST", "/", bytes.NewReader(test.body))
+		r.Header.Set("Content-Type", test.contentType)
+		if test.accept != "" {
+			r.Header.Set("Accept", test.accept)
+		}
+		rw := httptest.NewRecorder()
+		controller.Action((*EventController).Create).ServeHTTP(rw, r)
+		if rw.Code != test.code {
+			t.Errorf("%s: expected status %d, got %d: %s", test.contentType, test.code, rw.Code, rw.Body)
+			continue
+		}
+		if test.code != http.StatusCreated {
+			continue
+		}
+		if got := rw.Header().Get("Content-Type"); got != test.response {
+			t.Errorf("%s: expected Content-Type %q, got %q", test.contentType, test.response, got)
+		}
+
+		var out event
+		if test.response == MediaType {
+			dec := msgpack.NewDecoder(rw.Body)
+			dec.SetCustomStructTag("json")
+			err = dec.Decode(&out)
+		} else {
+			err = json.Unmarshal(rw.Body.Bytes(), &out)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if out != (event{"deploy", 2}) {
+			t.Errorf("%s: expected %v, got %v", test.contentType, event{"deploy", 2}, out)
+		}
+	}
+}
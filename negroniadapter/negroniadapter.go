@@ -0,0 +1,76 @@
+// Package negroniadapter lets controller actions run as negroni handlers.
+//
+// negroni handlers receive the next handler of the middleware stack along
+// with the request. Action turns an action into such a handler, and makes
+// the next handler available to the controller, which decides whether to
+// call it:
+//
+//	n := negroni.Classic()
+//	n.Use(negroniadapter.Action((*AuthController).Check))
+//	n.UseHandler(mux)
+//
+//	func (c *AuthController) Check() error {
+//		if !c.authorized() {
+//			return &controller.HTTPError{Code: http.StatusUnauthorized}
+//		}
+//		c.Next()
+//		return nil
+//	}
+package negroniadapter
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/codegangsta/controller"
+	"github.com/urfave/negroni"
+)
+
+type nextKey struct{}
+
+// stack is the rest of the negroni middleware stack of a request: the next
+// handler, and the ResponseWriter negroni handed to Action.
+type stack struct {
+	next http.HandlerFunc
+	rw   http.ResponseWriter
+}
+
+// Action is the negroni counterpart of controller.Action. The next handler
+// of the middleware stack is available to the controller through Next.
+func Action(action interface{}, opts ...controller.Option) negroni.Handler {
+	h := controller.Action(action, opts...)
+	return negroni.HandlerFunc(func(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+		h.ServeHTTP(rw, r.WithContext(context.WithValue(r.Context(), nextKey{}, &stack{next, rw})))
+	})
+}
+
+// Next returns the next handler of the negroni middleware stack r is
+// served by, or a handler doing nothing if r is not served by a handler
+// returned by Action.
+func Next(r *http.Request) http.HandlerFunc {
+	if s, ok := r.Context().Value(nextKey{}).(*stack); ok {
+		return s.next
+	}
+	return func(http.ResponseWriter, *http.Request) {}
+}
+
+// Controller is a controller.Base for controllers whose actions run as
+// negroni handlers.
+type Controller struct {
+	controller.Base
+}
+
+// Next calls the next handler of the negroni middleware stack with the
+// request of the controller. What the controller has written so far is sent
+// first, and the next handler gets the negroni.ResponseWriter of the stack,
+// as it would without the controller in between.
+func (c *Controller) Next() {
+	s, ok := c.Request.Context().Value(nextKey{}).(*stack)
+	if !ok {
+		return
+	}
+	if w, ok := c.ResponseWriter.(*controller.ResponseWriter); ok && w.Written() {
+		w.Flush()
+	}
+	s.next(s.rw, c.Request)
+}
@@ -0,0 +1,72 @@
+package negroniadapter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/codegangsta/controller"
+	"github.com/urfave/negroni"
+)
+
+type AuthController struct {
+	Controller
+}
+
+func (c *AuthController) Check() error {
+	if c.Request.Header.Get("Authorization") == "" {
+		return &controller.HTTPError{Code: http.StatusUnauthorized}
+	}
+	c.ResponseWriter.Header().Set("X-Checked", "1")
+	c.Next()
+	return nil
+}
+
+func TestAction(t *testing.T) {
+	n := negroni.New()
+	n.Use(Action((*AuthController).Check))
+	n.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.Write([]byte("secret"))
+	})
+
+	var tests = []struct {
+		auth string
+		code int
+		body string
+	}{
+		{"", http.StatusUnauthorized, "Unauthorized\n"},
+		{"Bearer token", http.StatusOK, "secret"},
+	}
+	for _, test := range tests {
+		r := httptest.NewRequest("GET", "/", nil)
+		if test.auth != "" {
+			r.Header.Set("Authorization", test.auth)
+		}
+		rw := httptest.NewRecorder()
+		n.ServeHTTP(rw, r)
+		if rw.Code != test.code || rw.Body.String() != test.body {
+			t.Errorf("Expected %d %q, got %d %q", test.code, test.body, rw.Code, rw.Body.String())
+		}
+	}
+}
+
+func TestNextResponseWriter(t *testing.T) {
+	n := negroni.New()
+	n.Use(Action((*AuthController).Check))
+	var got http.ResponseWriter
+	n.UseHandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		got = rw
+		rw.WriteHeader(http.StatusAccepted)
+	})
+
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("Authorization", "Bearer token")
+	rw := httptest.NewRecorder()
+	n.ServeHTTP(rw, r)
+	if _, ok := got.(negroni.ResponseWriter); !ok {
+		t.Errorf("Expected the next handler to get a negroni.ResponseWriter, got %T", got)
+	}
+	if rw.Code != http.StatusAccepted || rw.Header().Get("X-Checked") != "1" {
+		t.Errorf("Expected 202 with the header of the controller, got %d %v", rw.Code, rw.Header())
+	}
+}
@@ -0,0 +1,364 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+// OpenAPIInfo holds the metadata of the API described by an OpenAPI
+// document.
+type OpenAPIInfo struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// OpenAPI returns an OpenAPI 3 document describing the controller routes
+// mounted on the router. Every route becomes an operation identified by its
+// controller and action name and tagged with the controller name. Path
+// parameters are described with a schema derived from their constraint.
+// Actions taking an argument are documented with the query parameters or the
+// JSON request body the argument is bound from.
+//
+// Named struct types are described once under components/schemas and
+// referenced from there, which also describes recursive types. Embedded
+// structs are flattened into the struct embedding them, as encoding/json
+// does.
+//
+// Routes that match every method are left out, as OpenAPI has no way to
+// describe them.
+func (r *Router) OpenAPI(info OpenAPIInfo) ([]byte, error) {
+	doc := openAPIDocument{
+		OpenAPI: "3.0.3",
+		Info:    info,
+		Paths:   make(map[string]map[string]*openAPIOperation),
+	}
+	g := &schemaGenerator{
+		schemas: make(map[string]*openAPISchema),
+		names:   make(map[schemaKey]string),
+	}
+
+	ids := make(map[string]int)
+	for _, route := range r.Routes() {
+		if route.Method == "" {
+			continue
+		}
+		pattern, params, err := splitPath(route.Path)
+		if err != nil {
+			return nil, err
+		}
+
+		controller := route.Controller.Elem().Name()
+		id := controller + "." + route.Action
+		if ids[id]++; ids[id] > 1 {
+			id = fmt.Sprintf("%s_%d", id, ids[id])
+		}
+
+		op := &openAPIOperation{
+			OperationID: id,
+			Tags:        []string{controller},
+			Responses: map[string]openAPIResponse{
+				"200":     {Description: "Response of " + controller + "." + route.Action},
+				"default": {Description: "Error"},
+			},
+		}
+		for _, param := range params {
+			op.Parameters = append(op.Parameters, openAPIParameter{
+				Name:     param.name,
+				In:       "path",
+				Required: true,
+				Schema:   constraintSchema(param.constraint),
+			})
+		}
+		if route.Params != nil {
+			switch route.Method {
+			case http.MethodGet, http.MethodHead, http.MethodDelete:
+				op.Parameters = append(op.Parameters, g.queryParameters(route.Params)...)
+			default:
+				op.RequestBody = &openAPIRequestBody{
+					Required: true,
+					Content: map[string]openAPIMediaType{
+						"application/json": {Schema: g.typeSchema(route.Params, "json")},
+					},
+				}
+			}
+		}
+
+		path := strings.ReplaceAll(strings.ReplaceAll(pattern, "...}", "}"), "{$}", "")
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = make(map[string]*openAPIOperation)
+		}
+		doc.Paths[path][strings.ToLower(route.Method)] = op
+	}
+	if len(g.schemas) > 0 {
+		doc.Components = &openAPIComponents{Schemas: g.schemas}
+	}
+
+	return json.MarshalIndent(doc, "", "  ")
+}
+
+// OpenAPIHandler returns a handler serving the OpenAPI document of the
+// router as JSON. The document is generated on every request, so it always
+// reflects the routes mounted at that time. Mount it on any path:
+//
+//	router.Handle("GET /openapi.json", router.OpenAPIHandler(info))
+func (r *Router) OpenAPIHandler(info OpenAPIInfo) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		doc, err := r.OpenAPI(info)
+		if err != nil {
+			http.Error(rw, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		rw.Header().Set("Content-Type", "application/json")
+		rw.Write(doc)
+	})
+}
+
+// constraintSchema describes the values satisfying a path constraint.
+func constraintSchema(constraint string) *openAPISchema {
+	switch constraint {
+	case "":
+		return &openAPISchema{Type: "string"}
+	case "int":
+		return &openAPISchema{Type: "integer"}
+	case "uint":
+		minimum := 0
+		return &openAPISchema{Type: "integer", Minimum: &minimum}
+	case "uuid":
+		return &openAPISchema{Type: "string", Format: "uuid"}
+	}
+	if expr, ok := Constraints[constraint]; ok {
+		constraint = expr
+	}
+	return &openAPISchema{Type: "string", Pattern: "^(?:" + constraint + ")$"}
+}
+
+// schemaGenerator describes Go types with OpenAPI schemas, collecting the
+// schemas of named struct types as components.
+type schemaGenerator struct {
+	schemas map[string]*openAPISchema
+	names   map[schemaKey]string
+}
+
+// schemaKey identifies the schema of a struct type with fields named after
+// a struct tag.
+type schemaKey struct {
+	t   reflect.Type
+	tag string
+}
+
+// queryParameters describes the query parameters a struct is bound from.
+func (g *schemaGenerator) queryParameters(t reflect.Type) []openAPIParameter {
+	schema := g.structSchema(indirectType(t), "query")
+	var params []openAPIParameter
+	for _, name := range schema.order {
+		params = append(params, openAPIParameter{
+			Name:     name,
+			In:       "query",
+			Required: schema.isRequired(name),
+			Schema:   schema.Properties[name],
+		})
+	}
+	return params
+}
+
+// typeSchema describes values of type t, naming struct fields after the given
+// struct tag.
+func (g *schemaGenerator) typeSchema(t reflect.Type, tag string) *openAPISchema {
+	t = indirectType(t)
+	switch {
+	case t == timeType:
+		return &openAPISchema{Type: "string", Format: "date-time"}
+	case implementsText(t):
+		return &openAPISchema{Type: "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &openAPISchema{Type: "string"}
+	case reflect.Bool:
+		return &openAPISchema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &openAPISchema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &openAPISchema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		return &openAPISchema{Type: "array", Items: g.typeSchema(t.Elem(), tag)}
+	case reflect.Map:
+		return &openAPISchema{Type: "object", AdditionalProperties: g.typeSchema(t.Elem(), tag)}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.structSchema(t, tag)
+		}
+		return &openAPISchema{Ref: "#/components/schemas/" + g.component(t, tag)}
+	}
+	return &openAPISchema{}
+}
+
+// component returns the name of the component describing the named struct
+// type t, adding the component if needed. The name is taken before the
+// fields are described, so that fields referring back to t refer to it.
+func (g *schemaGenerator) component(t reflect.Type, tag string) string {
+	key := schemaKey{t, tag}
+	if name, ok := g.names[key]; ok {
+		return name
+	}
+	base := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '.' || r == '-' || r == '_' {
+			return r
+		}
+		return '_'
+	}, t.Name())
+	if tag != "json" {
+		base += "_" + tag
+	}
+	name := base
+	for i := 2; ; i++ {
+		if _, taken := g.schemas[name]; !taken {
+			break
+		}
+		name = fmt.Sprintf("%s_%d", base, i)
+	}
+	g.names[key] = name
+	g.schemas[name] = &openAPISchema{}
+	*g.schemas[name] = *g.structSchema(t, tag)
+	return name
+}
+
+// structSchema describes the struct type t inline.
+func (g *schemaGenerator) structSchema(t reflect.Type, tag string) *openAPISchema {
+	f := &structFields{
+		schema:    &openAPISchema{Type: "object", Properties: make(map[string]*openAPISchema)},
+		depths:    make(map[string]int),
+		required:  make(map[string]bool),
+		embedding: map[reflect.Type]bool{t: true},
+	}
+	g.addFields(f, t, tag, 0)
+	for _, name := range f.schema.order {
+		if f.required[name] {
+			f.schema.Required = append(f.schema.Required, name)
+		}
+	}
+	return f.schema
+}
+
+// structFields collects the properties of a struct schema.
+type structFields struct {
+	schema *openAPISchema
+	// depths holds the depth of the field each property was taken from.
+	depths   map[string]int
+	required map[string]bool
+	// embedding holds the structs being flattened, to stop at embedding
+	// cycles.
+	embedding map[reflect.Type]bool
+}
+
+// addFields adds the fields of t, embedded depth levels deep in the struct
+// described by f, to its properties. As for encoding/json, the fields
+// of untagged embedded structs are promoted, and fields that are embedded
+// less deeply win over others of the same name.
+func (g *schemaGenerator) addFields(f *structFields, t reflect.Type, tag string, depth int) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, tagged := field.Tag.Lookup(tag)
+		if name, _, _ = strings.Cut(name, ","); name == "-" {
+			continue
+		}
+		if ft := indirectType(field.Type); field.Anonymous && !tagged && ft.Kind() == reflect.Struct {
+			if !f.embedding[ft] {
+				f.embedding[ft] = true
+				g.addFields(f, ft, tag, depth+1)
+				delete(f.embedding, ft)
+			}
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		if d, ok := f.depths[name]; ok {
+			if d <= depth {
+				continue
+			}
+		} else {
+			f.schema.order = append(f.schema.order, name)
+		}
+		f.depths[name] = depth
+		f.schema.Properties[name] = g.typeSchema(field.Type, tag)
+		f.required[name] = false
+		for _, rule := range strings.Split(field.Tag.Get("validate"), ",") {
+			if rule == "required" {
+				f.required[name] = true
+			}
+		}
+	}
+}
+
+type openAPIDocument struct {
+	OpenAPI    string                                  `json:"openapi"`
+	Info       OpenAPIInfo                             `json:"info"`
+	Paths      map[string]map[string]*openAPIOperation `json:"paths"`
+	Components *openAPIComponents                      `json:"components,omitempty"`
+}
+
+type openAPIComponents struct {
+	Schemas map[string]*openAPISchema `json:"schemas"`
+}
+
+type openAPIOperation struct {
+	OperationID string                     `json:"operationId"`
+	Tags        []string                   `json:"tags,omitempty"`
+	Parameters  []openAPIParameter         `json:"parameters,omitempty"`
+	RequestBody *openAPIRequestBody        `json:"requestBody,omitempty"`
+	Responses   map[string]openAPIResponse `json:"responses"`
+}
+
+type openAPIParameter struct {
+	Name     string         `json:"name"`
+	In       string         `json:"in"`
+	Required bool           `json:"required"`
+	Schema   *openAPISchema `json:"schema"`
+}
+
+type openAPIRequestBody struct {
+	Required bool                        `json:"required"`
+	Content  map[string]openAPIMediaType `json:"content"`
+}
+
+type openAPIMediaType struct {
+	Schema *openAPISchema `json:"schema"`
+}
+
+type openAPISchema struct {
+	Ref                  string                    `json:"$ref,omitempty"`
+	Type                 string                    `json:"type,omitempty"`
+	Format               string                    `json:"format,omitempty"`
+	Pattern              string                    `json:"pattern,omitempty"`
+	Minimum              *int                      `json:"minimum,omitempty"`
+	Items                *openAPISchema            `json:"items,omitempty"`
+	Properties           map[string]*openAPISchema `json:"properties,omitempty"`
+	AdditionalProperties *openAPISchema            `json:"additionalProperties,omitempty"`
+	Required             []string                  `json:"required,omitempty"`
+
+	// order holds the property names in the order of the struct fields.
+	order []string
+}
+
+func (s *openAPISchema) isRequired(name string) bool {
+	for _, required := range s.Required {
+		if required == name {
+			return true
+		}
+	}
+	return false
+}
+
+type openAPIResponse struct {
+	Description string `json:"description"`
+}
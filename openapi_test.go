@@ -0,0 +1,106 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestOpenAPI(t *testing.T) {
+	router := new(Router)
+	Mount(router, "/api", (*RoutesController)(nil))
+	router.Handle("GET /openapi.json", router.OpenAPIHandler(OpenAPIInfo{Title: "Test", Version: "1.0"}))
+
+	rw := httptest.NewRecorder()
+	router.ServeHTTP(rw, httptest.NewRequest("GET", "/openapi.json", nil))
+	equals(t, http.StatusOK, rw.Code)
+	equals(t, "application/json", rw.Header().Get("Content-Type"))
+
+	var doc struct {
+		OpenAPI string
+		Info    OpenAPIInfo
+		Paths   map[string]map[string]struct {
+			OperationID string
+			Parameters  []struct {
+				Name   string
+				In     string
+				Schema map[string]interface{}
+			}
+			RequestBody struct {
+				Content map[string]struct {
+					Schema struct {
+						Ref string `json:"$ref"`
+					}
+				}
+			}
+			Responses map[string]interface{}
+		}
+		Components struct {
+			Schemas map[string]struct {
+				Type       string
+				Properties map[string]map[string]interface{}
+				Required   []string
+			}
+		}
+	}
+	ok(t, json.Unmarshal(rw.Body.Bytes(), &doc))
+
+	equals(t, "3.0.3", doc.OpenAPI)
+	equals(t, "Test", doc.Info.Title)
+	equals(t, 3, len(doc.Paths))
+
+	show := doc.Paths["/api/users/{id}"]["get"]
+	equals(t, "RoutesController.Show", show.OperationID)
+	equals(t, "id", show.Parameters[0].Name)
+	equals(t, "path", show.Parameters[0].In)
+	equals(t, "integer", show.Parameters[0].Schema["type"])
+
+	codes := doc.Paths["/api/codes/{id}"]["get"]
+	equals(t, "RoutesController.Show_2", codes.OperationID)
+	equals(t, "^(?:[A-Z]{3})$", codes.Parameters[0].Schema["pattern"])
+
+	_, ok := show.Responses["200"]
+	assert(t, ok, "expected a 200 response\n")
+
+	ref := doc.Paths["/api/users"]["post"].RequestBody.Content["application/json"].Schema.Ref
+	equals(t, "#/components/schemas/TestParams", ref)
+	create := doc.Components.Schemas["TestParams"]
+	equals(t, "object", create.Type)
+	equals(t, "string", create.Properties["name"]["type"])
+	equals(t, []string{"name"}, create.Required)
+}
+
+type openAPINode struct {
+	Name     string        `json:"name"`
+	Children []openAPINode `json:"children"`
+}
+
+type openAPITimestamps struct {
+	Created string `json:"created" validate:"required"`
+	Name    int    `json:"name"`
+}
+
+type openAPIPage struct {
+	openAPITimestamps
+	Name string       `json:"name"`
+	Root *openAPINode `json:"root"`
+}
+
+func TestOpenAPISchemas(t *testing.T) {
+	g := &schemaGenerator{schemas: make(map[string]*openAPISchema), names: make(map[schemaKey]string)}
+	schema := g.typeSchema(reflect.TypeOf(openAPIPage{}), "json")
+	equals(t, "#/components/schemas/openAPIPage", schema.Ref)
+
+	// Embedded structs are flattened, and shallower fields win.
+	page := g.schemas["openAPIPage"]
+	equals(t, []string{"created", "name", "root"}, page.order)
+	equals(t, "string", page.Properties["name"].Type)
+	equals(t, []string{"created"}, page.Required)
+
+	// Recursive types refer to themselves.
+	equals(t, "#/components/schemas/openAPINode", page.Properties["root"].Ref)
+	node := g.schemas["openAPINode"]
+	equals(t, "#/components/schemas/openAPINode", node.Properties["children"].Items.Ref)
+}
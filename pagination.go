@@ -0,0 +1,113 @@
+package controller
+
+import (
+	"fmt"
+	"math"
+	"net/url"
+	"strconv"
+)
+
+// Pagination describes the page of a collection requested by a client.
+type Pagination struct {
+	// Page is the requested page, starting at 1. For pages requested with an
+	// offset, it is the page the offset falls on.
+	Page int `json:"page"`
+	// PerPage is the number of items per page.
+	PerPage int `json:"per_page"`
+
+	// offset is the offset requested by the client, if hasOffset is set.
+	offset    int
+	hasOffset bool
+}
+
+// Offset returns the number of items preceding the page: the offset
+// requested by the client, or else the items of the preceding pages.
+func (p Pagination) Offset() int {
+	if p.hasOffset {
+		return p.offset
+	}
+	return (p.Page - 1) * p.PerPage
+}
+
+// Limit returns the number of items on the page. It is equal to PerPage and
+// provided for symmetry with Offset when building queries.
+func (p Pagination) Limit() int {
+	return p.PerPage
+}
+
+// TotalPages returns the number of pages needed for total items.
+func (p Pagination) TotalPages(total int) int {
+	if p.PerPage <= 0 {
+		return 0
+	}
+	return (total + p.PerPage - 1) / p.PerPage
+}
+
+var (
+	// DefaultPerPage is the page size used when a request does not ask for
+	// one.
+	DefaultPerPage = 20
+	// MaxPerPage is the largest page size a request may ask for.
+	MaxPerPage = 100
+)
+
+// Pagination parses the page of a collection requested by the query string.
+// The page can be requested either with page and per_page, or with limit and
+// offset, in which case Offset returns the offset as requested:
+//
+//	GET /users?page=3&per_page=50
+//	GET /users?limit=50&offset=100
+//
+// Missing values default to the first page and DefaultPerPage items per page.
+// Malformed or out of range values fail with an HTTPError with code 400,
+// including page sizes above MaxPerPage and pages whose offset does not fit
+// in an int.
+func (b *Base) Pagination() (Pagination, error) {
+	p := Pagination{Page: 1, PerPage: DefaultPerPage}
+	query := b.Request.URL.Query()
+
+	var err error
+	sizeParam := "per_page"
+	if query.Has("limit") || query.Has("offset") {
+		sizeParam = "limit"
+		if p.PerPage, err = queryInt(query, "limit", DefaultPerPage, 1); err != nil {
+			return p, err
+		}
+		if p.offset, err = queryInt(query, "offset", 0, 0); err != nil {
+			return p, err
+		}
+		p.hasOffset = true
+		p.Page = p.offset/p.PerPage + 1
+	} else {
+		if p.Page, err = queryInt(query, "page", 1, 1); err != nil {
+			return p, err
+		}
+		if p.PerPage, err = queryInt(query, "per_page", DefaultPerPage, 1); err != nil {
+			return p, err
+		}
+		if p.Page-1 > math.MaxInt/p.PerPage {
+			return p, invalidParam("page", "is too large")
+		}
+	}
+
+	if p.PerPage > MaxPerPage {
+		return p, invalidParam(sizeParam, fmt.Sprintf("exceeds the maximum of %d", MaxPerPage))
+	}
+	return p, nil
+}
+
+// queryInt returns the query parameter with the given name as an int of at
+// least min, or def if it is not present.
+func queryInt(query url.Values, name string, def, min int) (int, error) {
+	if !query.Has(name) {
+		return def, nil
+	}
+	n, err := strconv.Atoi(query.Get(name))
+	if err != nil {
+		return 0, invalidParam(name, "is not an integer")
+	}
+	if n < min {
+		return 0, invalidParam(name, fmt.Sprintf("must be at least %d", min))
+	}
+	return n, nil
+}
@@ -0,0 +1,48 @@
+package controller
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPagination(t *testing.T) {
+	var tests = []struct {
+		query   string
+		page    int
+		perPage int
+		offset  int
+		code    int
+	}{
+		{"", 1, 20, 0, 0},
+		{"page=3&per_page=50", 3, 50, 100, 0},
+		{"page=2", 2, 20, 20, 0},
+		{"limit=10&offset=25", 3, 10, 25, 0},
+		{"limit=50&offset=25", 1, 50, 25, 0},
+		{"offset=40", 3, 20, 40, 0},
+		{"page=0", 0, 0, 0, http.StatusBadRequest},
+		{"page=x", 0, 0, 0, http.StatusBadRequest},
+		{"page=9223372036854775807", 0, 0, 0, http.StatusBadRequest},
+		{"per_page=101", 0, 0, 0, http.StatusBadRequest},
+		{"limit=500", 0, 0, 0, http.StatusBadRequest},
+		{"offset=-1", 0, 0, 0, http.StatusBadRequest},
+	}
+
+	for _, test := range tests {
+		c := &Base{Request: httptest.NewRequest("GET", "/?"+test.query, nil)}
+		p, err := c.Pagination()
+		if test.code != 0 {
+			equals(t, test.code, errorCode(err))
+			continue
+		}
+		ok(t, err)
+		equals(t, test.page, p.Page)
+		equals(t, test.perPage, p.PerPage)
+		equals(t, test.offset, p.Offset())
+	}
+
+	p := Pagination{Page: 3, PerPage: 10}
+	equals(t, 20, p.Offset())
+	equals(t, 10, p.Limit())
+	equals(t, 5, p.TotalPages(41))
+}
@@ -0,0 +1,181 @@
+package controller
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Param returns the request parameter with the given name. Parameters are
+// looked up in the following order, and the first non-empty value is
+// returned:
+//
+//  1. path parameters, as set by http.ServeMux or one of the router adapters,
+//  2. the query string,
+//  3. form values from the request body.
+//
+// Multipart bodies are parsed with the limits configured on Base, like for
+// FormFile. Param returns an empty string if the parameter is not present
+// at all, or the body can not be parsed.
+func (b *Base) Param(name string) string {
+	if v := b.Request.PathValue(name); v != "" {
+		return v
+	}
+	if v := b.Request.URL.Query().Get(name); v != "" {
+		return v
+	}
+	return b.PostFormValue(name)
+}
+
+// PostFormValue returns the form value with the given name from the request
+// body, ignoring the query string. Unlike http.Request.PostFormValue, it
+// parses multipart bodies with the limits configured on Base.
+func (b *Base) PostFormValue(name string) string {
+	r := b.Request
+	if r.PostForm == nil {
+		if t, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); t == "multipart/form-data" {
+			parseMultipartForm(r, b.MaxMultipartMemory, b.MaxUploadBytes)
+		} else {
+			r.ParseForm()
+		}
+	}
+	return r.PostForm.Get(name)
+}
+
+var uuidPattern = regexp.MustCompile(`^` + Constraints["uuid"] + `$`)
+
+// ParamInt returns the parameter with the given name, as looked up by Param,
+// parsed as an int. Missing and malformed parameters fail with an HTTPError
+// with code 400, so actions can return the error as is:
+//
+//	id, err := c.ParamInt("id")
+//	if err != nil {
+//		return err
+//	}
+func (b *Base) ParamInt(name string) (int, error) {
+	n, err := b.paramInt(name, strconv.IntSize)
+	return int(n), err
+}
+
+// ParamIntDefault is like ParamInt, but returns def if the parameter is
+// missing or malformed.
+func (b *Base) ParamIntDefault(name string, def int) int {
+	if n, err := b.ParamInt(name); err == nil {
+		return n
+	}
+	return def
+}
+
+// ParamInt64 returns the parameter with the given name parsed as an int64,
+// failing like ParamInt.
+func (b *Base) ParamInt64(name string) (int64, error) {
+	return b.paramInt(name, 64)
+}
+
+// ParamInt64Default is like ParamInt64, but returns def if the parameter is
+// missing or malformed.
+func (b *Base) ParamInt64Default(name string, def int64) int64 {
+	if n, err := b.ParamInt64(name); err == nil {
+		return n
+	}
+	return def
+}
+
+// ParamBool returns the parameter with the given name parsed as a bool,
+// failing like ParamInt. It accepts the values understood by
+// strconv.ParseBool.
+func (b *Base) ParamBool(name string) (bool, error) {
+	s, err := b.requireParam(name)
+	if err != nil {
+		return false, err
+	}
+	v, err := strconv.ParseBool(s)
+	if err != nil {
+		return false, invalidParam(name, "is not a boolean")
+	}
+	return v, nil
+}
+
+// ParamBoolDefault is like ParamBool, but returns def if the parameter is
+// missing or malformed.
+func (b *Base) ParamBoolDefault(name string, def bool) bool {
+	if v, err := b.ParamBool(name); err == nil {
+		return v
+	}
+	return def
+}
+
+// ParamUUID returns the parameter with the given name if it is a UUID in its
+// canonical textual form, failing like ParamInt otherwise. The UUID is
+// returned in lower case.
+func (b *Base) ParamUUID(name string) (string, error) {
+	s, err := b.requireParam(name)
+	if err != nil {
+		return "", err
+	}
+	if !uuidPattern.MatchString(s) {
+		return "", invalidParam(name, "is not a UUID")
+	}
+	return strings.ToLower(s), nil
+}
+
+// ParamUUIDDefault is like ParamUUID, but returns def if the parameter is
+// missing or malformed.
+func (b *Base) ParamUUIDDefault(name string, def string) string {
+	if v, err := b.ParamUUID(name); err == nil {
+		return v
+	}
+	return def
+}
+
+// ParamTime returns the parameter with the given name parsed as a time,
+// failing like ParamInt. It accepts RFC 3339 as well as the formats of HTML
+// date and time inputs.
+func (b *Base) ParamTime(name string) (time.Time, error) {
+	s, err := b.requireParam(name)
+	if err != nil {
+		return time.Time{}, err
+	}
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, invalidParam(name, "is not a valid time")
+}
+
+// ParamTimeDefault is like ParamTime, but returns def if the parameter is
+// missing or malformed.
+func (b *Base) ParamTimeDefault(name string, def time.Time) time.Time {
+	if t, err := b.ParamTime(name); err == nil {
+		return t
+	}
+	return def
+}
+
+func (b *Base) paramInt(name string, bits int) (int64, error) {
+	s, err := b.requireParam(name)
+	if err != nil {
+		return 0, err
+	}
+	n, err := strconv.ParseInt(s, 10, bits)
+	if err != nil {
+		return 0, invalidParam(name, "is not an integer")
+	}
+	return n, nil
+}
+
+func (b *Base) requireParam(name string) (string, error) {
+	if s := b.Param(name); s != "" {
+		return s, nil
+	}
+	return "", &HTTPError{Code: http.StatusBadRequest, Err: fmt.Errorf("Missing parameter %q", name)}
+}
+
+func invalidParam(name, problem string) error {
+	return &HTTPError{Code: http.StatusBadRequest, Err: fmt.Errorf("Parameter %q %s", name, problem)}
+}
@@ -0,0 +1,81 @@
+package controller
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParam(t *testing.T) {
+	r := httptest.NewRequest("POST", "/users/1?id=2&q=search", strings.NewReader("id=3&q=body&name=gopher"))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.SetPathValue("id", "1")
+	c := &Base{Request: r}
+
+	equals(t, "1", c.Param("id"))
+	equals(t, "search", c.Param("q"))
+	equals(t, "gopher", c.Param("name"))
+	equals(t, "", c.Param("missing"))
+}
+
+func TestParamMultipart(t *testing.T) {
+	body := "--b\r\nContent-Disposition: form-data; name=\"name\"\r\n\r\ngopher\r\n--b--\r\n"
+	newRequest := func() *http.Request {
+		r := httptest.NewRequest("POST", "/", strings.NewReader(body))
+		r.Header.Set("Content-Type", "multipart/form-data; boundary=b")
+		return r
+	}
+
+	equals(t, "gopher", (&Base{Request: newRequest()}).Param("name"))
+
+	// Bodies above MaxUploadBytes are not parsed.
+	c := &Base{Request: newRequest(), MaxUploadBytes: 10}
+	equals(t, "", c.Param("name"))
+}
+
+func TestTypedParams(t *testing.T) {
+	r := httptest.NewRequest("GET", "/?n=42&big=9000000000&flag=true&id=0E2D4F7A-1B3C-4D5E-8F90-A1B2C3D4E5F6&day=2021-03-04&bad=x", nil)
+	c := &Base{Request: r}
+
+	n, err := c.ParamInt("n")
+	ok(t, err)
+	equals(t, 42, n)
+
+	big, err := c.ParamInt64("big")
+	ok(t, err)
+	equals(t, int64(9000000000), big)
+
+	flag, err := c.ParamBool("flag")
+	ok(t, err)
+	equals(t, true, flag)
+
+	id, err := c.ParamUUID("id")
+	ok(t, err)
+	equals(t, "0e2d4f7a-1b3c-4d5e-8f90-a1b2c3d4e5f6", id)
+
+	day, err := c.ParamTime("day")
+	ok(t, err)
+	equals(t, time.Date(2021, 3, 4, 0, 0, 0, 0, time.UTC), day)
+
+	_, err = c.ParamInt("missing")
+	equals(t, http.StatusBadRequest, errorCode(err))
+	equals(t, `Missing parameter "missing"`, err.Error())
+	_, err = c.ParamInt("bad")
+	equals(t, `Parameter "bad" is not an integer`, err.Error())
+	_, err = c.ParamBool("bad")
+	equals(t, http.StatusBadRequest, errorCode(err))
+	_, err = c.ParamUUID("bad")
+	equals(t, http.StatusBadRequest, errorCode(err))
+	_, err = c.ParamTime("bad")
+	equals(t, http.StatusBadRequest, errorCode(err))
+
+	equals(t, 7, c.ParamIntDefault("missing", 7))
+	equals(t, 7, c.ParamIntDefault("bad", 7))
+	equals(t, 42, c.ParamIntDefault("n", 7))
+	equals(t, int64(7), c.ParamInt64Default("bad", 7))
+	equals(t, true, c.ParamBoolDefault("missing", true))
+	equals(t, "none", c.ParamUUIDDefault("bad", "none"))
+	equals(t, time.Time{}, c.ParamTimeDefault("bad", time.Time{}))
+}
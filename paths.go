@@ -0,0 +1,86 @@
+package controller
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// TrailingSlash selects how a PathPolicy treats request paths that end in a
+// slash.
+type TrailingSlash int
+
+const (
+	// TrailingSlashStrict routes paths as they are, so "/users/" and "/users"
+	// are different routes.
+	TrailingSlashStrict TrailingSlash = iota
+	// TrailingSlashRedirect redirects requests to the path without the
+	// trailing slash.
+	TrailingSlashRedirect
+	// TrailingSlashStrip removes the trailing slash before routing the request,
+	// without redirecting.
+	TrailingSlashStrip
+)
+
+// PathPolicy normalizes request paths before they reach a router, so that
+// routes resolve consistently without registering every spelling of a path:
+//
+//	policy := controller.PathPolicy{
+//		TrailingSlash:   controller.TrailingSlashRedirect,
+//		CaseInsensitive: true,
+//	}
+//	http.ListenAndServe(":3000", policy.Handler(mux))
+type PathPolicy struct {
+	// TrailingSlash selects the handling of trailing slashes. The root path
+	// "/" is never changed.
+	TrailingSlash TrailingSlash
+	// CaseInsensitive routes requests by their lowercased path. Note that path
+	// parameters are lowercased as well.
+	CaseInsensitive bool
+}
+
+// Handler returns a handler that applies the policy to requests before
+// passing them on to h.
+func (p PathPolicy) Handler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		path := r.URL.Path
+		trimmed := path
+		if p.TrailingSlash != TrailingSlashStrict && len(path) > 1 {
+			trimmed = strings.TrimRight(path, "/")
+			if trimmed == "" {
+				trimmed = "/"
+			}
+		}
+
+		if trimmed != path && p.TrailingSlash == TrailingSlashRedirect {
+			// Leading slashes are collapsed, since a Location starting with
+			// "//" would send the client to another host.
+			u := *r.URL
+			u.Path, u.RawPath = "/"+strings.TrimLeft(trimmed, "/"), ""
+			code := http.StatusMovedPermanently
+			if r.Method != http.MethodGet && r.Method != http.MethodHead {
+				code = http.StatusPermanentRedirect
+			}
+			http.Redirect(rw, r, u.RequestURI(), code)
+			return
+		}
+
+		if p.CaseInsensitive {
+			trimmed = strings.ToLower(trimmed)
+		}
+		if trimmed != path {
+			r = withPath(r, trimmed)
+		}
+		h.ServeHTTP(rw, r)
+	})
+}
+
+// withPath returns a shallow copy of r with its URL path replaced by path.
+func withPath(r *http.Request, path string) *http.Request {
+	r2 := new(http.Request)
+	*r2 = *r
+	r2.URL = new(url.URL)
+	*r2.URL = *r.URL
+	r2.URL.Path, r2.URL.RawPath = path, ""
+	return r2
+}
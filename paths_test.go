@@ -0,0 +1,40 @@
+package controller
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPathPolicy(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/users", func(rw http.ResponseWriter, r *http.Request) {
+		rw.Write([]byte("users"))
+	})
+
+	var tests = []struct {
+		policy   PathPolicy
+		method   string
+		path     string
+		code     int
+		location string
+	}{
+		{PathPolicy{}, "GET", "/users", http.StatusOK, ""},
+		{PathPolicy{}, "GET", "/users/", http.StatusNotFound, ""},
+		{PathPolicy{}, "GET", "/Users", http.StatusNotFound, ""},
+		{PathPolicy{TrailingSlash: TrailingSlashRedirect}, "GET", "/users/?page=2", http.StatusMovedPermanently, "/users?page=2"},
+		{PathPolicy{TrailingSlash: TrailingSlashRedirect}, "POST", "/users//", http.StatusPermanentRedirect, "/users"},
+		{PathPolicy{TrailingSlash: TrailingSlashRedirect}, "GET", "//evil.example/", http.StatusMovedPermanently, "/evil.example"},
+		{PathPolicy{TrailingSlash: TrailingSlashRedirect}, "GET", "///evil.example//", http.StatusMovedPermanently, "/evil.example"},
+		{PathPolicy{TrailingSlash: TrailingSlashStrip}, "GET", "/users/", http.StatusOK, ""},
+		{PathPolicy{CaseInsensitive: true}, "GET", "/USERS", http.StatusOK, ""},
+		{PathPolicy{TrailingSlash: TrailingSlashStrip, CaseInsensitive: true}, "GET", "/Users/", http.StatusOK, ""},
+	}
+
+	for _, test := range tests {
+		rw := httptest.NewRecorder()
+		test.policy.Handler(mux).ServeHTTP(rw, httptest.NewRequest(test.method, test.path, nil))
+		equals(t, test.code, rw.Code)
+		equals(t, test.location, rw.Header().Get("Location"))
+	}
+}
@@ -0,0 +1,74 @@
+// Package protobuf adds support for Protocol Buffers request bodies to
+// controllers.
+//
+// Importing the package registers a binder for the application/x-protobuf
+// media type, so Base.Bind and the arguments of actions accept protobuf
+// bodies alongside JSON ones:
+//
+//	import _ "github.com/codegangsta/controller/protobuf"
+//
+//	func (c *UserController) Create(in *pb.CreateUserRequest) error
+//
+// Controllers that only accept protobuf can embed Controller and call
+// BindProto explicitly.
+package protobuf
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+
+	"github.com/codegangsta/controller"
+	"google.golang.org/protobuf/proto"
+)
+
+// MediaType is the media type of protobuf request bodies.
+const MediaType = "application/x-protobuf"
+
+// mediaTypes lists the media types protobuf bodies are sent with in the wild.
+var mediaTypes = []string{MediaType, "application/protobuf", "application/vnd.google.protobuf"}
+
+func init() {
+	for _, mediaType := range mediaTypes {
+		controller.RegisterBinder(mediaType, controller.BinderFunc(bind))
+	}
+}
+
+func bind(r *http.Request, dst interface{}) error {
+	msg, ok := dst.(proto.Message)
+	if !ok {
+		return fmt.Errorf("Binding target %T is not a proto.Message", dst)
+	}
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+	if err := proto.Unmarshal(data, msg); err != nil {
+		return &controller.HTTPError{Code: http.StatusBadRequest, Err: fmt.Errorf("Malformed protobuf: %w", err)}
+	}
+	return nil
+}
+
+// Controller is a controller.Base with protobuf support, meant to be
+// embedded in your own controller struct.
+type Controller struct {
+	controller.Base
+}
+
+// BindProto decodes the protobuf request body into dst. Requests with a
+// Content-Type other than protobuf fail with an HTTPError with code 415,
+// malformed bodies with code 400. Otherwise it behaves like Base.Bind,
+// including the size limit, default values and validation.
+func (c *Controller) BindProto(dst proto.Message) error {
+	t, _, _ := mime.ParseMediaType(c.Request.Header.Get("Content-Type"))
+	for _, mediaType := range mediaTypes {
+		if t == mediaType {
+			return c.Bind(dst)
+		}
+	}
+	return &controller.HTTPError{
+		Code: http.StatusUnsupportedMediaType,
+		Err:  fmt.Errorf("Unsupported Content-Type %q, expected %s", c.Request.Header.Get("Content-Type"), MediaType),
+	}
+}
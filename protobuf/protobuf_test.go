@@ -0,0 +1,63 @@
+package protobuf
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/codegangsta/controller"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+type GreetController struct {
+	Controller
+}
+
+func (c *GreetController) Create() error {
+	var name wrapperspb.StringValue
+	if err := c.BindProto(&name); err != nil {
+		return err
+	}
+	c.ResponseWriter.Write([]byte("hello " + name.GetValue()))
+	return nil
+}
+
+func (c *GreetController) Update(in *wrapperspb.StringValue) error {
+	c.ResponseWriter.Write([]byte("updated " + in.GetValue()))
+	return nil
+}
+
+func TestBindProto(t *testing.T) {
+	body, err := proto.Marshal(wrapperspb.String("gopher"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var tests = []struct {
+		action      interface{}
+		contentType string
+		body        []byte
+		code        int
+		response    string
+	}{
+		{(*GreetController).Create, MediaType, body, http.StatusOK, "hello gopher"},
+		{(*GreetController).Create, "application/json", []byte(`{}`), http.StatusUnsupportedMediaType, ""},
+		{(*GreetController).Create, MediaType, []byte{0xff, 0xff}, http.StatusBadRequest, ""},
+		{(*GreetController).Update, "application/protobuf", body, http.StatusOK, "updated gopher"},
+	}
+
+	for _, test := range tests {
+		r := httptest.NewRequest("POST", "/", bytes.NewReader(test.body))
+		r.Header.Set("Content-Type", test.contentType)
+		rw := httptest.NewRecorder()
+		controller.Action(test.action).ServeHTTP(rw, r)
+		if rw.Code != test.code {
+			t.Errorf("%s: expected status %d, got %d: %s", test.contentType, test.code, rw.Code, rw.Body)
+		}
+		if test.response != "" && rw.Body.String() != test.response {
+			t.Errorf("%s: expected body %q, got %q", test.contentType, test.response, rw.Body)
+		}
+	}
+}
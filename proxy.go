@@ -0,0 +1,68 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+)
+
+// ProxyController is a base for controllers that forward requests to an
+// upstream server, so that gateway routes share the lifecycle of the rest of
+// the application. Init configures the upstream and rewrites the request,
+// and the Proxy action forwards it:
+//
+//	type BillingGateway struct {
+//		controller.ProxyController
+//	}
+//
+//	func (c *BillingGateway) Init(rw http.ResponseWriter, r *http.Request) error {
+//		c.ProxyController.Init(rw, r)
+//		c.Upstream = billingURL
+//		c.Request.Header.Set("Authorization", "Bearer "+billingToken)
+//		c.ModifyResponse = func(resp *http.Response) error {
+//			resp.Header.Del("Server")
+//			return nil
+//		}
+//		return nil
+//	}
+//
+//	mux.Handle("/billing/", controller.Action((*BillingGateway).Proxy))
+type ProxyController struct {
+	Base
+
+	// Upstream is the URL requests are forwarded to. The path of the request
+	// is appended to its path.
+	Upstream *url.URL
+	// Transport performs the upstream requests. http.DefaultTransport is used
+	// if it is nil.
+	Transport http.RoundTripper
+	// ModifyResponse, if set, filters the upstream response before it is
+	// copied to the client. If it returns an error, the client is answered
+	// with 502 Bad Gateway through the Error method of the controller.
+	ModifyResponse func(*http.Response) error
+}
+
+// Proxy forwards the request to Upstream and copies the response to the
+// client. Failed upstream requests are reported with 502 Bad Gateway. The
+// request carries X-Forwarded-For, X-Forwarded-Host and X-Forwarded-Proto
+// headers.
+func (c *ProxyController) Proxy() error {
+	if c.Upstream == nil {
+		return errors.New("No upstream to proxy to")
+	}
+	var proxyErr error
+	proxy := &httputil.ReverseProxy{
+		Rewrite: func(r *httputil.ProxyRequest) {
+			r.SetURL(c.Upstream)
+			r.SetXForwarded()
+		},
+		Transport:      c.Transport,
+		ModifyResponse: c.ModifyResponse,
+		ErrorHandler: func(rw http.ResponseWriter, r *http.Request, err error) {
+			proxyErr = &HTTPError{Code: http.StatusBadGateway, Err: err}
+		},
+	}
+	proxy.ServeHTTP(c.ResponseWriter, c.Request)
+	return proxyErr
+}
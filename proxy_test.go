@@ -0,0 +1,57 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+type GatewayController struct {
+	ProxyController
+}
+
+var upstreamURL *url.URL
+
+func (c *GatewayController) Init(rw http.ResponseWriter, r *http.Request) error {
+	c.ProxyController.Init(rw, r)
+	c.Upstream = upstreamURL
+	c.Request.Header.Set("X-Gateway-Token", "secret")
+	c.ModifyResponse = func(resp *http.Response) error {
+		if resp.Header.Get("X-Fail") != "" {
+			return errors.New("Filtered")
+		}
+		resp.Header.Del("X-Internal")
+		return nil
+	}
+	return nil
+}
+
+func TestProxyController(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.Header().Set("X-Internal", "1")
+		if r.URL.Query().Get("fail") != "" {
+			rw.Header().Set("X-Fail", "1")
+		}
+		rw.Write([]byte(r.URL.Path + " " + r.Header.Get("X-Gateway-Token")))
+	}))
+	defer upstream.Close()
+	upstreamURL, _ = url.Parse(upstream.URL + "/api")
+
+	h := Action((*GatewayController).Proxy)
+	rw := httptest.NewRecorder()
+	h.ServeHTTP(rw, httptest.NewRequest("GET", "/users", nil))
+	equals(t, http.StatusOK, rw.Code)
+	equals(t, "/api/users secret", rw.Body.String())
+	equals(t, "", rw.Header().Get("X-Internal"))
+
+	rw = httptest.NewRecorder()
+	h.ServeHTTP(rw, httptest.NewRequest("GET", "/users?fail=1", nil))
+	equals(t, http.StatusBadGateway, rw.Code)
+
+	upstream.Close()
+	rw = httptest.NewRecorder()
+	h.ServeHTTP(rw, httptest.NewRequest("GET", "/users", nil))
+	equals(t, http.StatusBadGateway, rw.Code)
+}
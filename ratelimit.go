@@ -0,0 +1,129 @@
+package controller
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Limiter decides whether requests may proceed. TokenBucket limits requests
+// in memory; implementations backed by a shared store such as Redis limit
+// requests across several servers.
+type Limiter interface {
+	// Allow reports whether a request identified by key may proceed, and if
+	// not, how long the client should wait before retrying.
+	Allow(ctx context.Context, key string) (ok bool, retryAfter time.Duration, err error)
+}
+
+// RateLimit returns a filter limiting requests with limiter, by the key
+// returned by key for each request, or by the IP address of the client, as
+// returned by Base.ClientIP, if key is nil. Requests over the limit fail with
+// an HTTPError with code 429, carrying a Retry-After header:
+//
+//	var apiLimit = controller.NewTokenBucket(100, time.Minute)
+//
+//	func (*APIController) ActionFilters() []controller.Filter {
+//		return []controller.Filter{controller.RateLimit(apiLimit, nil)}
+//	}
+//
+// All the actions a limiter is attached to share its limit, so limiting
+// actions separately takes a limiter for each. Errors of the limiter fail
+// the request with 500 Internal Server Error.
+func RateLimit(limiter Limiter, key func(r *http.Request) string) Filter {
+	if key == nil {
+		key = func(r *http.Request) string { return clientIP(r).String() }
+	}
+	return func(c Controller, rw http.ResponseWriter, r *http.Request) error {
+		ok, retryAfter, err := limiter.Allow(r.Context(), key(r))
+		if err != nil || ok {
+			return err
+		}
+		seconds := int(math.Ceil(retryAfter.Seconds()))
+		if seconds < 1 {
+			seconds = 1
+		}
+		return &HTTPError{
+			Code:   http.StatusTooManyRequests,
+			Header: http.Header{"Retry-After": {strconv.Itoa(seconds)}},
+		}
+	}
+}
+
+// TokenBucket is a Limiter allowing bursts of requests per key up to its
+// limit, with tokens for new requests added back at a steady rate.
+type TokenBucket struct {
+	limit float64
+	// interval is the time it takes to add a token.
+	interval time.Duration
+	// now returns the current time, for tests.
+	now func() time.Time
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	// prune is the number of buckets above which full buckets are removed.
+	prune int
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// NewTokenBucket returns a TokenBucket allowing limit requests per key
+// during each period.
+func NewTokenBucket(limit int, period time.Duration) *TokenBucket {
+	if limit <= 0 || period <= 0 {
+		panic("controller: NewTokenBucket called with a non-positive limit or period")
+	}
+	return &TokenBucket{
+		limit:    float64(limit),
+		interval: period / time.Duration(limit),
+		now:      time.Now,
+		buckets:  make(map[string]*bucket),
+		prune:    1024,
+	}
+}
+
+// Allow takes a token from the bucket of key, if there is one left.
+func (tb *TokenBucket) Allow(ctx context.Context, key string) (bool, time.Duration, error) {
+	now := tb.now()
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+
+	b, ok := tb.buckets[key]
+	if !ok {
+		if len(tb.buckets) >= tb.prune {
+			tb.removeFull(now)
+		}
+		b = &bucket{tokens: tb.limit, last: now}
+		tb.buckets[key] = b
+	}
+	b.tokens = tb.fill(b, now)
+	b.last = now
+	if b.tokens < 1 {
+		return false, time.Duration((1 - b.tokens) * float64(tb.interval)), nil
+	}
+	b.tokens--
+	return true, 0, nil
+}
+
+// fill returns the number of tokens in b at now.
+func (tb *TokenBucket) fill(b *bucket, now time.Time) float64 {
+	return math.Min(tb.limit, b.tokens+float64(now.Sub(b.last))/float64(tb.interval))
+}
+
+// removeFull removes the buckets that have filled up again, which behave
+// like new ones.
+func (tb *TokenBucket) removeFull(now time.Time) {
+	for key, b := range tb.buckets {
+		if tb.fill(b, now) >= tb.limit {
+			delete(tb.buckets, key)
+		}
+	}
+	if n := 2 * len(tb.buckets); n > tb.prune {
+		tb.prune = n
+	}
+}
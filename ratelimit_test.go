@@ -0,0 +1,62 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type RateLimitController struct {
+	Base
+}
+
+func (c *RateLimitController) Index() error {
+	return c.Text(http.StatusOK, "ok")
+}
+
+func TestRateLimit(t *testing.T) {
+	now := time.Unix(0, 0)
+	limiter := NewTokenBucket(2, time.Minute)
+	limiter.now = func() time.Time { return now }
+	h := Action((*RateLimitController).Index, UseFilters(RateLimit(limiter, nil)))
+
+	serve := func(addr string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.RemoteAddr = addr
+		rw := httptest.NewRecorder()
+		h.ServeHTTP(rw, r)
+		return rw
+	}
+	equals(t, http.StatusOK, serve("10.0.0.1:1234").Code)
+	equals(t, http.StatusOK, serve("10.0.0.1:1235").Code)
+	rw := serve("10.0.0.1:1236")
+	equals(t, http.StatusTooManyRequests, rw.Code)
+	equals(t, "30", rw.Header().Get("Retry-After"))
+
+	// Clients are limited separately.
+	equals(t, http.StatusOK, serve("10.0.0.2:1234").Code)
+
+	// Tokens are added back over time.
+	now = now.Add(20 * time.Second)
+	rw = serve("10.0.0.1:1234")
+	equals(t, "10", rw.Header().Get("Retry-After"))
+	now = now.Add(10 * time.Second)
+	equals(t, http.StatusOK, serve("10.0.0.1:1234").Code)
+}
+
+func TestTokenBucketPrune(t *testing.T) {
+	now := time.Unix(0, 0)
+	limiter := NewTokenBucket(1, time.Second)
+	limiter.now = func() time.Time { return now }
+	limiter.prune = 2
+
+	ctx := context.Background()
+	limiter.Allow(ctx, "a")
+	limiter.Allow(ctx, "b")
+	now = now.Add(time.Second)
+	ok, _, _ := limiter.Allow(ctx, "c")
+	assert(t, ok, "expected a new key to be allowed\n")
+	equals(t, 1, len(limiter.buckets))
+}
@@ -0,0 +1,425 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// JSON writes v encoded as JSON with the given status code and an
+// application/json Content-Type. The value is encoded before anything is
+// written, so if encoding fails the error is returned and the response is
+// left untouched for the action to report the error:
+//
+//	func (c *UserController) Show() error {
+//		user, err := findUser(c.Request.PathValue("id"))
+//		if err != nil {
+//			return err
+//		}
+//		return c.JSON(http.StatusOK, user)
+//	}
+//
+// Clients can ask for indented output with ?pretty=1 or with a pretty
+// parameter on the JSON media type they accept, unless AllowPrettyJSON is
+// disabled.
+func (b *Base) JSON(code int, v interface{}) error {
+	buf := getBuffer()
+	defer putBuffer(buf)
+	if err := b.encodeJSON(buf, v); err != nil {
+		return err
+	}
+	return b.write(code, "application/json; charset=utf-8", buf.Bytes())
+}
+
+// AllowPrettyJSON lets requests ask the JSON helpers of Base for indented
+// output, for reading responses in a browser while debugging:
+//
+//	GET /users/1?pretty=1
+//	Accept: application/json; pretty=true
+//
+// Set it to false to always send compact JSON.
+var AllowPrettyJSON = true
+
+// encodeJSON appends v encoded as JSON to buf, indented if the request asks
+// for it. Nothing is appended if encoding fails.
+func (b *Base) encodeJSON(buf *bytes.Buffer, v interface{}) error {
+	enc := json.NewEncoder(buf)
+	if AllowPrettyJSON && b.Request != nil && wantsPretty(b.Request) {
+		enc.SetIndent("", "  ")
+	}
+	if err := enc.Encode(v); err != nil {
+		return err
+	}
+	// Drop the newline terminating the value.
+	buf.Truncate(buf.Len() - 1)
+	return nil
+}
+
+// wantsPretty reports whether r asks for indented output with a pretty query
+// parameter or a pretty parameter of an accepted JSON media type. A pretty
+// parameter without a value counts as true.
+func wantsPretty(r *http.Request) bool {
+	if values, ok := r.URL.Query()["pretty"]; ok {
+		return isTrue(values[0])
+	}
+	for _, header := range r.Header.Values("Accept") {
+		for _, part := range strings.Split(header, ",") {
+			t, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+			if err != nil || (t != "application/json" && !strings.HasSuffix(t, "+json")) {
+				continue
+			}
+			if pretty, ok := params["pretty"]; ok {
+				return isTrue(pretty)
+			}
+		}
+	}
+	return false
+}
+
+func isTrue(s string) bool {
+	if s == "" {
+		return true
+	}
+	b, err := strconv.ParseBool(s)
+	return err == nil && b
+}
+
+// jsonpCallback matches the callback names accepted by JSONP: JavaScript
+// identifiers, optionally qualified with dots.
+var jsonpCallback = regexp.MustCompile(`^[A-Za-z_$][0-9A-Za-z_$]*(\.[A-Za-z_$][0-9A-Za-z_$]*)*$`)
+
+// JSONP writes v like JSON, wrapped in a call to the function named by the
+// callback query parameter if the request has one, for legacy cross-domain
+// clients loading the response with a script tag:
+//
+//	GET /users/1?callback=showUser
+//	/**/ typeof showUser === 'function' && showUser({"id":1});
+//
+// Callback names must be JavaScript identifiers, optionally qualified with
+// dots, anything else fails with an HTTPError with code 400. Requests
+// without a callback get plain JSON.
+func (b *Base) JSONP(code int, v interface{}) error {
+	callback := b.Request.URL.Query().Get("callback")
+	if callback == "" {
+		return b.JSON(code, v)
+	}
+	if !jsonpCallback.MatchString(callback) {
+		return &HTTPError{Code: http.StatusBadRequest, Err: fmt.Errorf("Invalid JSONP callback %q", callback)}
+	}
+	buf := getBuffer()
+	defer putBuffer(buf)
+	fmt.Fprintf(buf, "/**/ typeof %s === 'function' && %s(", callback, callback)
+	if err := b.encodeJSON(buf, v); err != nil {
+		return err
+	}
+	buf.WriteString(");")
+	b.ResponseWriter.Header().Set("X-Content-Type-Options", "nosniff")
+	return b.write(code, "text/javascript; charset=utf-8", buf.Bytes())
+}
+
+// bufferPool holds the buffers responses are encoded into before they are
+// written. Encoding into a buffer first lets the helpers report encoding
+// errors before any bytes are sent, and pooling the buffers avoids
+// allocating them anew for every response.
+var bufferPool = sync.Pool{
+	New: func() interface{} { return new(bytes.Buffer) },
+}
+
+// maxPooledBuffer is the capacity above which buffers are not returned to
+// the pool, so that a few large responses do not pin their memory.
+const maxPooledBuffer = 64 << 10
+
+func getBuffer() *bytes.Buffer {
+	return bufferPool.Get().(*bytes.Buffer)
+}
+
+func putBuffer(buf *bytes.Buffer) {
+	if buf.Cap() > maxPooledBuffer {
+		return
+	}
+	buf.Reset()
+	bufferPool.Put(buf)
+}
+
+// write sends data with the given status code and Content-Type.
+func (b *Base) write(code int, contentType string, data []byte) error {
+	b.ResponseWriter.Header().Set("Content-Type", contentType)
+	b.writeHeader(code)
+	_, err := b.ResponseWriter.Write(data)
+	return err
+}
+
+// XML writes v encoded as XML with the given status code and an
+// application/xml Content-Type, preceded by the standard XML header. Like
+// JSON, it returns encoding errors without writing anything.
+func (b *Base) XML(code int, v interface{}) error {
+	buf := getBuffer()
+	defer putBuffer(buf)
+	buf.WriteString(xml.Header)
+	if err := xml.NewEncoder(buf).Encode(v); err != nil {
+		return err
+	}
+	return b.write(code, "application/xml; charset=utf-8", buf.Bytes())
+}
+
+// Text writes s as a plain text response with the given status code.
+func (b *Base) Text(code int, s string) error {
+	return b.write(code, "text/plain; charset=utf-8", []byte(s))
+}
+
+// Textf formats according to a format specifier and writes the result as a
+// plain text response with the given status code.
+func (b *Base) Textf(code int, format string, args ...interface{}) error {
+	return b.Text(code, fmt.Sprintf(format, args...))
+}
+
+// Redirect redirects the request to url with the given status code, which
+// must be a 3xx code. The url may be relative to the request path:
+//
+//	return c.Redirect(http.StatusSeeOther, "/users/"+id)
+func (b *Base) Redirect(code int, url string) error {
+	if code < 300 || code > 399 {
+		return fmt.Errorf("Invalid redirect code %d", code)
+	}
+	http.Redirect(b.ResponseWriter, b.Request, url, code)
+	b.written = true
+	return nil
+}
+
+// RedirectBack redirects the request to the page it came from according to
+// its Referer header, or to fallback if there is none. Only referers that
+// are absolute URLs with the scheme and host of the request, or paths
+// starting with a single slash, are followed, so that RedirectBack can not
+// be used to send users to other sites. Requests other than GET and HEAD
+// are redirected with
+// 303 See Other, so forms posted to an action land on a page fetched with
+// GET, and others with 302 Found.
+func (b *Base) RedirectBack(fallback string) error {
+	target := fallback
+	if ref, ok := localReferer(b.Request); ok {
+		target = ref
+	}
+	code := http.StatusFound
+	if b.Request.Method != http.MethodGet && b.Request.Method != http.MethodHead {
+		code = http.StatusSeeOther
+	}
+	return b.Redirect(code, target)
+}
+
+// localReferer returns the Referer header of r if it points to the
+// application itself.
+func localReferer(r *http.Request) (string, bool) {
+	raw := r.Referer()
+	ref, err := url.Parse(raw)
+	if err != nil || raw == "" {
+		return "", false
+	}
+	if ref.IsAbs() {
+		scheme := "http"
+		if isHTTPS(r) {
+			scheme = "https"
+		}
+		return ref.String(), ref.Scheme == scheme && ref.Opaque == "" && ref.Host == r.Host
+	}
+	// Browsers treat "//host" and "/\host" as references to another host.
+	local := strings.HasPrefix(raw, "/") && !strings.HasPrefix(raw, "//") && !strings.HasPrefix(raw, "/\\")
+	return ref.String(), local
+}
+
+// Status writes a response with the given status code and no body. For
+// codes that do not permit a body, such as 204 No Content and 304 Not
+// Modified, headers describing a body are removed:
+//
+//	return c.Status(http.StatusAccepted)
+func (b *Base) Status(code int) error {
+	if !bodyAllowed(code) {
+		h := b.ResponseWriter.Header()
+		h.Del("Content-Type")
+		h.Del("Content-Length")
+		h.Del("Transfer-Encoding")
+	}
+	b.writeHeader(code)
+	return nil
+}
+
+// NoContent writes an empty 204 No Content response.
+func (b *Base) NoContent() error {
+	return b.Status(http.StatusNoContent)
+}
+
+// Written reports whether a response has been written. For controllers
+// served by Action, whose ResponseWriter is a *ResponseWriter, this covers
+// everything written to the ResponseWriter. Otherwise only responses written
+// through the response helpers of Base, such as JSON, Redirect or Status,
+// are tracked.
+func (b *Base) Written() bool {
+	if w, ok := b.ResponseWriter.(*ResponseWriter); ok {
+		return w.Written()
+	}
+	return b.written
+}
+
+func (b *Base) writeHeader(code int) {
+	b.ResponseWriter.WriteHeader(code)
+	b.written = true
+}
+
+// bodyAllowed reports whether a response with the given status code may
+// have a body.
+func bodyAllowed(code int) bool {
+	switch {
+	case code >= 100 && code <= 199, code == http.StatusNoContent, code == http.StatusNotModified:
+		return false
+	}
+	return true
+}
+
+// File serves the file at path, a path on the local file system. The
+// content type is derived from the file extension, or sniffed from the
+// content if the extension is unknown, and conditional and range requests are
+// answered. Missing files are reported with an HTTPError with code 404,
+// unreadable files with code 403.
+//
+// Unlike StaticController, File does not restrict path to a directory, so it
+// must not be derived from the request without validation.
+func (b *Base) File(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fsError(err)
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return fsError(err)
+	}
+	if info.IsDir() {
+		return &HTTPError{Code: http.StatusNotFound, Err: fmt.Errorf("%s is a directory", path)}
+	}
+	http.ServeContent(b.ResponseWriter, b.Request, info.Name(), info.ModTime(), f)
+	b.written = true
+	return nil
+}
+
+// Attachment serves the file at path like File, with a Content-Disposition
+// header asking browsers to download it as filename instead of displaying
+// it:
+//
+//	return c.Attachment("/var/reports/2024-q1.csv", "Report Q1 2024.csv")
+func (b *Base) Attachment(path, filename string) error {
+	disposition := mime.FormatMediaType("attachment", map[string]string{"filename": filename})
+	if disposition == "" {
+		return fmt.Errorf("Invalid attachment filename %q", filename)
+	}
+	b.ResponseWriter.Header().Set("Content-Disposition", disposition)
+	err := b.File(path)
+	if err != nil {
+		b.ResponseWriter.Header().Del("Content-Disposition")
+	}
+	return err
+}
+
+// StreamFlushInterval is the interval at which Stream flushes the data
+// written so far to the client. Zero flushes after every write.
+var StreamFlushInterval = 100 * time.Millisecond
+
+// Stream writes a 200 OK response of the given content type with the data fn
+// writes to w, without buffering it in memory. Written data is flushed to the
+// client every StreamFlushInterval, and once more when fn returns:
+//
+//	return c.Stream("text/csv", func(w io.Writer) error {
+//		for rows.Next() {
+//			...
+//			fmt.Fprintf(w, "%d,%s\n", id, name)
+//		}
+//		return rows.Err()
+//	})
+//
+// The error returned by fn is returned by Stream. As the status code has been
+// sent by then, the error can not change it anymore.
+func (b *Base) Stream(contentType string, fn func(w io.Writer) error) error {
+	return b.stream(http.StatusOK, contentType, fn)
+}
+
+func (b *Base) stream(code int, contentType string, fn func(w io.Writer) error) error {
+	h := b.ResponseWriter.Header()
+	h.Set("Content-Type", contentType)
+	h.Del("Content-Length")
+	b.writeHeader(code)
+
+	w := &flushWriter{w: b.ResponseWriter, rc: http.NewResponseController(b.ResponseWriter), last: time.Now()}
+	err := fn(w)
+	w.flush()
+	return err
+}
+
+// flushWriter flushes the data written to a ResponseWriter every
+// StreamFlushInterval.
+type flushWriter struct {
+	w    io.Writer
+	rc   *http.ResponseController
+	last time.Time
+}
+
+func (w *flushWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	if err == nil && time.Since(w.last) >= StreamFlushInterval {
+		w.flush()
+	}
+	return n, err
+}
+
+func (w *flushWriter) flush() {
+	// Writers that can not flush deliver the data when the handler returns.
+	w.rc.Flush()
+	w.last = time.Now()
+}
+
+// CSV streams a CSV document with the given status code, for export
+// actions. The header row is written first unless it is nil, then rows is
+// called to write the records:
+//
+//	c.ResponseWriter.Header().Set("Content-Disposition", `attachment; filename="users.csv"`)
+//	return c.CSV(http.StatusOK, []string{"id", "email"}, func(w *csv.Writer) error {
+//		for rows.Next() {
+//			...
+//			if err := w.Write([]string{id, email}); err != nil {
+//				return err
+//			}
+//		}
+//		return rows.Err()
+//	})
+//
+// Records are streamed like with Stream, so the data set is never held in
+// memory as a whole. The response is sent as an attachment unless a
+// Content-Disposition header has been set already.
+func (b *Base) CSV(code int, header []string, rows func(w *csv.Writer) error) error {
+	if b.ResponseWriter.Header().Get("Content-Disposition") == "" {
+		b.ResponseWriter.Header().Set("Content-Disposition", "attachment")
+	}
+	return b.stream(code, "text/csv; charset=utf-8", func(w io.Writer) error {
+		cw := csv.NewWriter(w)
+		if header != nil {
+			if err := cw.Write(header); err != nil {
+				return err
+			}
+		}
+		err := rows(cw)
+		cw.Flush()
+		if err != nil {
+			return err
+		}
+		return cw.Error()
+	})
+}
@@ -0,0 +1,290 @@
+package controller
+
+import (
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestJSON(t *testing.T) {
+	rw := httptest.NewRecorder()
+	c := &Base{ResponseWriter: rw}
+	ok(t, c.JSON(http.StatusCreated, map[string]int{"id": 1}))
+	equals(t, http.StatusCreated, rw.Code)
+	equals(t, "application/json; charset=utf-8", rw.Header().Get("Content-Type"))
+	equals(t, `{"id":1}`, rw.Body.String())
+
+	// Values that can not be encoded leave the response untouched.
+	rw = httptest.NewRecorder()
+	c = &Base{ResponseWriter: rw}
+	assert(t, c.JSON(http.StatusOK, math.Inf(1)) != nil, "expected an error for +Inf\n")
+	equals(t, "", rw.Header().Get("Content-Type"))
+	equals(t, 0, rw.Body.Len())
+}
+
+func TestPrettyJSON(t *testing.T) {
+	const pretty = "{\n  \"id\": 1\n}"
+
+	var tests = []struct {
+		url    string
+		accept string
+		allow  bool
+		body   string
+	}{
+		{"/?pretty=1", "", true, pretty},
+		{"/?pretty", "", true, pretty},
+		{"/?pretty=false", "", true, `{"id":1}`},
+		{"/", "application/json; pretty=true", true, pretty},
+		{"/", "text/html, application/vnd.api+json; pretty=1", true, pretty},
+		{"/", "application/json", true, `{"id":1}`},
+		{"/?pretty=1", "", false, `{"id":1}`},
+	}
+
+	defer func(allow bool) { AllowPrettyJSON = allow }(AllowPrettyJSON)
+	for _, test := range tests {
+		AllowPrettyJSON = test.allow
+		r := httptest.NewRequest("GET", test.url, nil)
+		if test.accept != "" {
+			r.Header.Set("Accept", test.accept)
+		}
+		rw := httptest.NewRecorder()
+		c := &Base{Request: r, ResponseWriter: rw}
+		ok(t, c.JSON(http.StatusOK, map[string]int{"id": 1}))
+		equals(t, test.body, rw.Body.String())
+	}
+}
+
+func TestJSONP(t *testing.T) {
+	var tests = []struct {
+		query       string
+		code        int
+		contentType string
+		body        string
+	}{
+		{"", http.StatusOK, "application/json; charset=utf-8", `{"id":1}`},
+		{"?callback=showUser", http.StatusOK, "text/javascript; charset=utf-8", `/**/ typeof showUser === 'function' && showUser({"id":1});`},
+		{"?callback=app.users.$show", http.StatusOK, "text/javascript; charset=utf-8", `/**/ typeof app.users.$show === 'function' && app.users.$show({"id":1});`},
+		{"?callback=alert(1)//", http.StatusBadRequest, "", ""},
+		{"?callback=1abc", http.StatusBadRequest, "", ""},
+	}
+
+	for _, test := range tests {
+		rw := httptest.NewRecorder()
+		c := &Base{Request: httptest.NewRequest("GET", "/users/1"+test.query, nil), ResponseWriter: rw}
+		err := c.JSONP(http.StatusOK, map[string]int{"id": 1})
+		if test.code != http.StatusOK {
+			equals(t, test.code, errorCode(err))
+			continue
+		}
+		ok(t, err)
+		equals(t, test.contentType, rw.Header().Get("Content-Type"))
+		equals(t, test.body, rw.Body.String())
+	}
+}
+
+func TestXML(t *testing.T) {
+	type user struct {
+		XMLName struct{} `xml:"user"`
+		Name    string   `xml:"name"`
+	}
+
+	rw := httptest.NewRecorder()
+	c := &Base{ResponseWriter: rw}
+	ok(t, c.XML(http.StatusOK, user{Name: "gopher"}))
+	equals(t, http.StatusOK, rw.Code)
+	equals(t, "application/xml; charset=utf-8", rw.Header().Get("Content-Type"))
+	equals(t, xml.Header+"<user><name>gopher</name></user>", rw.Body.String())
+
+	rw = httptest.NewRecorder()
+	c = &Base{ResponseWriter: rw}
+	assert(t, c.XML(http.StatusOK, make(chan int)) != nil, "expected an error for a channel\n")
+	equals(t, 0, rw.Body.Len())
+}
+
+func TestText(t *testing.T) {
+	rw := httptest.NewRecorder()
+	c := &Base{ResponseWriter: rw}
+	ok(t, c.Text(http.StatusAccepted, "queued"))
+	equals(t, http.StatusAccepted, rw.Code)
+	equals(t, "text/plain; charset=utf-8", rw.Header().Get("Content-Type"))
+	equals(t, "queued", rw.Body.String())
+
+	rw = httptest.NewRecorder()
+	c = &Base{ResponseWriter: rw}
+	ok(t, c.Textf(http.StatusOK, "%d jobs in %s", 3, "default"))
+	equals(t, "3 jobs in default", rw.Body.String())
+}
+
+func TestRedirect(t *testing.T) {
+	rw := httptest.NewRecorder()
+	c := &Base{Request: httptest.NewRequest("POST", "/users", nil), ResponseWriter: rw}
+	ok(t, c.Redirect(http.StatusSeeOther, "/users/1"))
+	equals(t, http.StatusSeeOther, rw.Code)
+	equals(t, "/users/1", rw.Header().Get("Location"))
+
+	rw = httptest.NewRecorder()
+	c = &Base{Request: httptest.NewRequest("GET", "/", nil), ResponseWriter: rw}
+	assert(t, c.Redirect(http.StatusOK, "/") != nil, "expected an error for a non-3xx code\n")
+	equals(t, "", rw.Header().Get("Location"))
+}
+
+func TestRedirectBack(t *testing.T) {
+	var tests = []struct {
+		method   string
+		referer  string
+		code     int
+		location string
+	}{
+		{"POST", "http://example.com/users?page=2", http.StatusSeeOther, "http://example.com/users?page=2"},
+		{"GET", "/users", http.StatusFound, "/users"},
+		{"POST", "", http.StatusSeeOther, "/dashboard"},
+		{"POST", "http://evil.example.org/phish", http.StatusSeeOther, "/dashboard"},
+		{"POST", "https://example.com/users", http.StatusSeeOther, "/dashboard"},
+		{"POST", "https:evil.example", http.StatusSeeOther, "/dashboard"},
+		{"POST", "//evil.example", http.StatusSeeOther, "/dashboard"},
+		{"POST", "/\\evil.example", http.StatusSeeOther, "/dashboard"},
+		{"POST", "users", http.StatusSeeOther, "/dashboard"},
+	}
+
+	for _, test := range tests {
+		r := httptest.NewRequest(test.method, "http://example.com/users/1", nil)
+		if test.referer != "" {
+			r.Header.Set("Referer", test.referer)
+		}
+		rw := httptest.NewRecorder()
+		c := &Base{Request: r, ResponseWriter: rw}
+		ok(t, c.RedirectBack("/dashboard"))
+		equals(t, test.code, rw.Code)
+		equals(t, test.location, rw.Header().Get("Location"))
+	}
+}
+
+func TestStatus(t *testing.T) {
+	var tests = []struct {
+		code        int
+		contentType string
+	}{
+		{http.StatusAccepted, "text/csv"},
+		{http.StatusNoContent, ""},
+		{http.StatusNotModified, ""},
+	}
+
+	for _, test := range tests {
+		rw := httptest.NewRecorder()
+		rw.Header().Set("Content-Type", "text/csv")
+		c := &Base{ResponseWriter: rw}
+		assert(t, !c.Written(), "expected no response to be written\n")
+		ok(t, c.Status(test.code))
+		assert(t, c.Written(), "expected the response to be written\n")
+		equals(t, test.code, rw.Code)
+		equals(t, test.contentType, rw.Header().Get("Content-Type"))
+		equals(t, 0, rw.Body.Len())
+	}
+
+	rw := httptest.NewRecorder()
+	c := &Base{ResponseWriter: rw}
+	ok(t, c.NoContent())
+	equals(t, http.StatusNoContent, rw.Code)
+}
+
+func TestFile(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "report.csv")
+	ok(t, os.WriteFile(name, []byte("id,name\n1,gopher\n"), 0644))
+
+	rw := httptest.NewRecorder()
+	c := &Base{Request: httptest.NewRequest("GET", "/", nil), ResponseWriter: rw}
+	ok(t, c.File(name))
+	equals(t, http.StatusOK, rw.Code)
+	equals(t, "text/csv; charset=utf-8", rw.Header().Get("Content-Type"))
+	equals(t, "id,name\n1,gopher\n", rw.Body.String())
+
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("Range", "bytes=0-6")
+	rw = httptest.NewRecorder()
+	c = &Base{Request: r, ResponseWriter: rw}
+	ok(t, c.Attachment(name, "Report Q1.csv"))
+	equals(t, http.StatusPartialContent, rw.Code)
+	equals(t, `attachment; filename="Report Q1.csv"`, rw.Header().Get("Content-Disposition"))
+	equals(t, "id,name", rw.Body.String())
+
+	for _, path := range []string{filepath.Join(dir, "missing.csv"), dir} {
+		rw = httptest.NewRecorder()
+		c = &Base{Request: httptest.NewRequest("GET", "/", nil), ResponseWriter: rw}
+		err := c.Attachment(path, "report.csv")
+		equals(t, http.StatusNotFound, errorCode(err))
+		equals(t, "", rw.Header().Get("Content-Disposition"))
+	}
+}
+
+func TestStream(t *testing.T) {
+	defer func(interval time.Duration) { StreamFlushInterval = interval }(StreamFlushInterval)
+	StreamFlushInterval = 0
+
+	rw := httptest.NewRecorder()
+	c := &Base{ResponseWriter: rw}
+	var flushed []bool
+	err := c.Stream("text/csv", func(w io.Writer) error {
+		for i := 0; i < 3; i++ {
+			fmt.Fprintf(w, "%d\n", i)
+			flushed = append(flushed, rw.Flushed)
+			rw.Flushed = false
+		}
+		return errors.New("cut off")
+	})
+	equals(t, "cut off", err.Error())
+	equals(t, http.StatusOK, rw.Code)
+	equals(t, "text/csv", rw.Header().Get("Content-Type"))
+	equals(t, "0\n1\n2\n", rw.Body.String())
+	equals(t, []bool{true, true, true}, flushed)
+	assert(t, c.Written(), "expected the response to be written\n")
+}
+
+func TestResponseBuffers(t *testing.T) {
+	// Failed encodings must not leave partial output in pooled buffers.
+	rw := httptest.NewRecorder()
+	c := &Base{ResponseWriter: rw}
+	assert(t, c.XML(http.StatusOK, map[string]int{"id": 1}) != nil, "expected an error for a map\n")
+	assert(t, c.JSON(http.StatusOK, []interface{}{1, math.NaN()}) != nil, "expected an error for NaN\n")
+	for i := 0; i < 10; i++ {
+		rw = httptest.NewRecorder()
+		c = &Base{ResponseWriter: rw}
+		ok(t, c.JSON(http.StatusOK, i))
+		equals(t, strconv.Itoa(i), rw.Body.String())
+	}
+}
+
+func TestCSV(t *testing.T) {
+	rw := httptest.NewRecorder()
+	c := &Base{ResponseWriter: rw}
+	err := c.CSV(http.StatusOK, []string{"id", "name"}, func(w *csv.Writer) error {
+		w.Write([]string{"1", "gopher"})
+		return w.Write([]string{"2", "mole, the"})
+	})
+	ok(t, err)
+	equals(t, http.StatusOK, rw.Code)
+	equals(t, "text/csv; charset=utf-8", rw.Header().Get("Content-Type"))
+	equals(t, "attachment", rw.Header().Get("Content-Disposition"))
+	equals(t, "id,name\n1,gopher\n2,\"mole, the\"\n", rw.Body.String())
+
+	rw = httptest.NewRecorder()
+	rw.Header().Set("Content-Disposition", `attachment; filename="users.csv"`)
+	c = &Base{ResponseWriter: rw}
+	err = c.CSV(http.StatusOK, nil, func(w *csv.Writer) error {
+		w.Write([]string{"1", "gopher"})
+		return errors.New("cut off")
+	})
+	equals(t, "cut off", err.Error())
+	equals(t, `attachment; filename="users.csv"`, rw.Header().Get("Content-Disposition"))
+	equals(t, "1,gopher\n", rw.Body.String())
+}
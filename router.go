@@ -0,0 +1,66 @@
+package controller
+
+import (
+	"net/http"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// Router is an http.ServeMux that keeps track of the controller routes
+// mounted on it, so that applications can list them, for instance to print a
+// route table or to check in tests that every action is routed:
+//
+//	router := new(controller.Router)
+//	controller.Mount(router, "/api", (*UserController)(nil))
+//
+//	for _, route := range router.Routes() {
+//		fmt.Println(route.Method, route.Path, route.Controller, route.Action)
+//	}
+//
+// A Router must not be copied after first use.
+type Router struct {
+	http.ServeMux
+
+	mu     sync.Mutex
+	routes []Route
+}
+
+// Route describes a controller action mounted on a Router.
+type Route struct {
+	// Method is the request method the route matches, or empty if it matches
+	// every method.
+	Method string
+	// Path is the path pattern of the route, including its constraints.
+	Path string
+	// Controller is the type of the controller the action belongs to.
+	Controller reflect.Type
+	// Action is the name of the action method.
+	Action string
+	// Params is the type of the struct bound for the argument of the action,
+	// or nil if the action takes no argument.
+	Params reflect.Type
+}
+
+// Routes returns the routes mounted on the router, in the order they were
+// registered.
+func (r *Router) Routes() []Route {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Route(nil), r.routes...)
+}
+
+func (r *Router) addRoute(route Route) {
+	r.mu.Lock()
+	r.routes = append(r.routes, route)
+	r.mu.Unlock()
+}
+
+// actionName returns the name of the method referenced by a method
+// expression.
+func actionName(action reflect.Value) string {
+	name := runtime.FuncForPC(action.Pointer()).Name()
+	name = name[strings.LastIndexByte(name, '.')+1:]
+	return strings.TrimSuffix(name, "-fm")
+}
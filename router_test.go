@@ -0,0 +1,26 @@
+package controller
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestRouterRoutes(t *testing.T) {
+	router := new(Router)
+	Mount(router, "/api", (*RoutesController)(nil))
+
+	ctrl := reflect.TypeOf((*RoutesController)(nil))
+	equals(t, []Route{
+		{"GET", "/api/users/{id:int}", ctrl, "Show", nil},
+		{"GET", "/api/codes/{id:[A-Z]{3}}", ctrl, "Show", nil},
+		{"", "/api/ping", ctrl, "Ping", nil},
+		{"POST", "/api/users", ctrl, "Create", reflect.TypeOf(TestParams{})},
+	}, router.Routes())
+
+	rw := httptest.NewRecorder()
+	router.ServeHTTP(rw, httptest.NewRequest("GET", "/api/users/3", nil))
+	equals(t, http.StatusOK, rw.Code)
+	equals(t, "user 3", rw.Body.String())
+}
@@ -0,0 +1,140 @@
+package controller
+
+import (
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultSecureHeaders are the response headers set by SecureHeaders. They
+// can be adjusted for the whole application at startup.
+var DefaultSecureHeaders = map[string]string{
+	"X-Content-Type-Options":     "nosniff",
+	"X-Frame-Options":            "DENY",
+	"Referrer-Policy":            "strict-origin-when-cross-origin",
+	"Cross-Origin-Opener-Policy": "same-origin",
+}
+
+// SecureHeaders returns a middleware setting security headers on every
+// response: DefaultSecureHeaders, overridden by headers. An empty value in
+// headers leaves out the default header of that name. Apply it to single
+// actions with Use, or to whole controllers with MiddlewareProvider:
+//
+//	func (*AdminController) Middleware() []func(http.Handler) http.Handler {
+//		return []func(http.Handler) http.Handler{
+//			controller.SecureHeaders(map[string]string{"Content-Security-Policy": "default-src 'self'"}),
+//		}
+//	}
+//
+// The headers are set before the controller is constructed, so they are
+// kept on error responses, and actions can still override them.
+func SecureHeaders(headers map[string]string) func(http.Handler) http.Handler {
+	merged := make(map[string]string, len(DefaultSecureHeaders)+len(headers))
+	for key, value := range DefaultSecureHeaders {
+		merged[http.CanonicalHeaderKey(key)] = value
+	}
+	for key, value := range headers {
+		merged[http.CanonicalHeaderKey(key)] = value
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			h := rw.Header()
+			for key, value := range merged {
+				if value != "" {
+					h.Set(key, value)
+				}
+			}
+			next.ServeHTTP(rw, r)
+		})
+	}
+}
+
+// HTTPS redirects plain HTTP requests to HTTPS and sends the
+// Strict-Transport-Security header, so that browsers use HTTPS right away
+// afterwards. Its Handler method is a middleware, attached to a controller
+// with MiddlewareProvider, to single actions with Use, or to a tree of
+// controllers by wrapping their mux:
+//
+//	https := &controller.HTTPS{MaxAge: 365 * 24 * time.Hour, IncludeSubdomains: true}
+//	http.ListenAndServe(":80", https.Handler(mux))
+//
+// Requests received over TLS, or forwarded by one of TrustedProxies with
+// X-Forwarded-Proto set to https, are secure.
+type HTTPS struct {
+	// MaxAge is how long browsers should only use HTTPS for the host. The
+	// Strict-Transport-Security header is not sent if it is zero.
+	MaxAge time.Duration
+	// IncludeSubdomains extends the policy to all subdomains of the host.
+	IncludeSubdomains bool
+	// Preload asks for the host to be included in the preload lists of
+	// browsers.
+	Preload bool
+	// Host is the host to redirect to, such as "example.com:8443". It
+	// defaults to the host of the request, without its port.
+	Host string
+}
+
+// Handler is a middleware redirecting plain HTTP requests to next to HTTPS,
+// with 301 Moved Permanently for GET and HEAD requests and 308 Permanent
+// Redirect for others, which keeps their method and body.
+func (s *HTTPS) Handler(next http.Handler) http.Handler {
+	var hsts string
+	if s.MaxAge > 0 {
+		hsts = "max-age=" + strconv.FormatInt(int64(s.MaxAge/time.Second), 10)
+		if s.IncludeSubdomains {
+			hsts += "; includeSubDomains"
+		}
+		if s.Preload {
+			hsts += "; preload"
+		}
+	}
+
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if isHTTPS(r) {
+			if hsts != "" {
+				rw.Header().Set("Strict-Transport-Security", hsts)
+			}
+			next.ServeHTTP(rw, r)
+			return
+		}
+		host := s.Host
+		if host == "" {
+			host = r.Host
+			if h, _, err := net.SplitHostPort(host); err == nil {
+				host = h
+				if strings.Contains(host, ":") {
+					host = "[" + host + "]"
+				}
+			}
+		}
+		code := http.StatusPermanentRedirect
+		if r.Method == http.MethodGet || r.Method == http.MethodHead {
+			code = http.StatusMovedPermanently
+		}
+		http.Redirect(rw, r, "https://"+host+r.URL.RequestURI(), code)
+	})
+}
+
+// isHTTPS reports whether the client sent r over HTTPS. X-Forwarded-Proto is
+// only believed from trusted proxies, and only its right-most value, which
+// the proxy the request was received from appended, since the client
+// controls the values before it.
+func isHTTPS(r *http.Request) bool {
+	if r.TLS != nil {
+		return true
+	}
+	addr, err := netip.ParseAddrPort(r.RemoteAddr)
+	if err != nil || !trusted(addr.Addr().Unmap()) {
+		return false
+	}
+	forwarded := r.Header.Values("X-Forwarded-Proto")
+	if len(forwarded) == 0 {
+		return false
+	}
+	last := forwarded[len(forwarded)-1]
+	proto := last[strings.LastIndex(last, ",")+1:]
+	return strings.EqualFold(strings.TrimSpace(proto), "https")
+}
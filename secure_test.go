@@ -0,0 +1,88 @@
+package controller
+
+import (
+	"crypto/tls"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+)
+
+type SecureController struct {
+	Base
+}
+
+func (*SecureController) Middleware() []func(http.Handler) http.Handler {
+	return []func(http.Handler) http.Handler{SecureHeaders(map[string]string{
+		"content-security-policy": "default-src 'self'",
+		"X-Frame-Options":         "",
+	})}
+}
+
+func (c *SecureController) Index() error {
+	return c.Text(http.StatusOK, "index")
+}
+
+func (c *SecureController) Fail() error {
+	return errors.New("Failed")
+}
+
+func TestSecureHeaders(t *testing.T) {
+	for _, action := range []interface{}{(*SecureController).Index, (*SecureController).Fail} {
+		rw := httptest.NewRecorder()
+		Action(action).ServeHTTP(rw, httptest.NewRequest("GET", "/", nil))
+		equals(t, "nosniff", rw.Header().Get("X-Content-Type-Options"))
+		equals(t, "default-src 'self'", rw.Header().Get("Content-Security-Policy"))
+		equals(t, "", rw.Header().Get("X-Frame-Options"))
+	}
+}
+
+func TestHTTPS(t *testing.T) {
+	defer func() { TrustedProxies = nil }()
+	TrustedProxies = []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}
+
+	h := (&HTTPS{MaxAge: 365 * 24 * time.Hour, IncludeSubdomains: true}).Handler(Action((*SecureController).Index))
+
+	var tests = []struct {
+		method   string
+		url      string
+		remote   string
+		proto    string
+		tls      bool
+		code     int
+		location string
+	}{
+		{"GET", "http://example.com:8080/users?page=2", "192.0.2.1:1234", "", false, http.StatusMovedPermanently, "https://example.com/users?page=2"},
+		{"POST", "http://example.com/users", "192.0.2.1:1234", "", false, http.StatusPermanentRedirect, "https://example.com/users"},
+		{"GET", "http://example.com/", "192.0.2.1:1234", "https", false, http.StatusMovedPermanently, "https://example.com/"},
+		{"GET", "http://example.com/", "10.0.0.1:1234", "https", false, http.StatusOK, ""},
+		{"GET", "http://example.com/", "10.0.0.1:1234", "http", false, http.StatusMovedPermanently, "https://example.com/"},
+		{"GET", "http://example.com/", "10.0.0.1:1234", "https, http", false, http.StatusMovedPermanently, "https://example.com/"},
+		{"GET", "http://example.com/", "10.0.0.1:1234", "http, https", false, http.StatusOK, ""},
+		{"GET", "https://example.com/", "192.0.2.1:1234", "", true, http.StatusOK, ""},
+	}
+
+	for _, test := range tests {
+		r := httptest.NewRequest(test.method, test.url, nil)
+		r.RemoteAddr = test.remote
+		if test.proto != "" {
+			r.Header.Set("X-Forwarded-Proto", test.proto)
+		}
+		if !test.tls {
+			r.TLS = nil
+		} else if r.TLS == nil {
+			r.TLS = &tls.ConnectionState{}
+		}
+		rw := httptest.NewRecorder()
+		h.ServeHTTP(rw, r)
+		equals(t, test.code, rw.Code)
+		equals(t, test.location, rw.Header().Get("Location"))
+		if test.code == http.StatusOK {
+			equals(t, "max-age=31536000; includeSubDomains", rw.Header().Get("Strict-Transport-Security"))
+		} else {
+			equals(t, "", rw.Header().Get("Strict-Transport-Security"))
+		}
+	}
+}
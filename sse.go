@@ -0,0 +1,173 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SSEKeepAlive is the interval at which event streams send a comment to the
+// client while no event is sent, so that proxies do not close idle
+// connections. Zero disables keep-alives.
+var SSEKeepAlive = 15 * time.Second
+
+// errStreamClosed is returned by EventStream.Send once the stream has been
+// closed.
+var errStreamClosed = errors.New("Event stream closed")
+
+// SSE starts a Server-Sent Events response and returns the stream to send
+// events to:
+//
+//	func (c *FeedController) Watch() error {
+//		events := c.SSE()
+//		for {
+//			select {
+//			case item := <-c.Feed.Updates():
+//				if err := events.Send("item", item); err != nil {
+//					return nil
+//				}
+//			case <-events.Done():
+//				return nil
+//			}
+//		}
+//	}
+//
+// Events are flushed to the client as they are sent. The stream is closed
+// when the client goes away, and at the latest once the controller is
+// destroyed, so actions need not close it themselves.
+func (b *Base) SSE() *EventStream {
+	h := b.ResponseWriter.Header()
+	h.Set("Content-Type", "text/event-stream")
+	h.Set("Cache-Control", "no-cache")
+	h.Set("X-Accel-Buffering", "no")
+	h.Del("Content-Length")
+	b.writeHeader(http.StatusOK)
+
+	s := &EventStream{
+		w:    b.ResponseWriter,
+		rc:   http.NewResponseController(b.ResponseWriter),
+		ctx:  b.Context(),
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	s.flush()
+	go s.keepAlive()
+	b.Defer(s.Close)
+	return s
+}
+
+// EventStream is a Server-Sent Events response, as started by Base.SSE. Its
+// methods may be called from several goroutines.
+type EventStream struct {
+	w   http.ResponseWriter
+	rc  *http.ResponseController
+	ctx context.Context
+
+	mu  sync.Mutex
+	err error
+
+	once sync.Once
+	stop chan struct{}
+	done chan struct{}
+}
+
+// Send sends an event to the client. The event name may be empty for
+// unnamed events. Strings and byte slices are sent as they are, other data
+// is encoded as JSON. Send fails once the client has gone away or the stream
+// has been closed.
+func (s *EventStream) Send(event string, data interface{}) error {
+	if strings.ContainsAny(event, "\r\n") {
+		return fmt.Errorf("Invalid event name %q", event)
+	}
+	var payload []byte
+	switch data := data.(type) {
+	case string:
+		payload = []byte(data)
+	case []byte:
+		payload = data
+	default:
+		var err error
+		if payload, err = json.Marshal(data); err != nil {
+			return err
+		}
+	}
+
+	var buf bytes.Buffer
+	if event != "" {
+		fmt.Fprintf(&buf, "event: %s\n", event)
+	}
+	for _, line := range strings.Split(strings.ReplaceAll(string(payload), "\r\n", "\n"), "\n") {
+		fmt.Fprintf(&buf, "data: %s\n", line)
+	}
+	buf.WriteByte('\n')
+	return s.write(buf.Bytes())
+}
+
+// Done returns a channel that is closed when the client goes away.
+func (s *EventStream) Done() <-chan struct{} {
+	return s.ctx.Done()
+}
+
+// Close ends the stream and stops the keep-alives. The response is complete
+// once the action returns.
+func (s *EventStream) Close() {
+	s.once.Do(func() { close(s.stop) })
+	<-s.done
+	s.mu.Lock()
+	if s.err == nil {
+		s.err = errStreamClosed
+	}
+	s.mu.Unlock()
+}
+
+// write sends p to the client, unless the stream has failed or the client
+// has gone away.
+func (s *EventStream) write(p []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err == nil {
+		s.err = s.ctx.Err()
+	}
+	if s.err != nil {
+		return s.err
+	}
+	if _, err := s.w.Write(p); err != nil {
+		s.err = err
+		return err
+	}
+	s.flush()
+	return nil
+}
+
+func (s *EventStream) flush() {
+	// Writers that can not flush deliver the events when the handler returns.
+	s.rc.Flush()
+}
+
+// keepAlive sends keep-alive comments until the stream is closed or the
+// client goes away.
+func (s *EventStream) keepAlive() {
+	defer close(s.done)
+	var tick <-chan time.Time
+	if SSEKeepAlive > 0 {
+		ticker := time.NewTicker(SSEKeepAlive)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-s.ctx.Done():
+			return
+		case <-tick:
+			s.write([]byte(": keep-alive\n\n"))
+		}
+	}
+}
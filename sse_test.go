@@ -0,0 +1,58 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type SSEController struct {
+	Base
+}
+
+func (c *SSEController) Watch() error {
+	events := c.SSE()
+	if err := events.Send("", "hello\nworld"); err != nil {
+		return err
+	}
+	if err := events.Send("user", map[string]int{"id": 1}); err != nil {
+		return err
+	}
+	if c.Request.URL.Query().Get("wait") != "" {
+		time.Sleep(50 * time.Millisecond)
+	}
+	return nil
+}
+
+func (c *SSEController) Disconnected() error {
+	events := c.SSE()
+	<-events.Done()
+	if err := events.Send("late", "data"); err == nil {
+		return c.Text(http.StatusOK, "expected Send to fail")
+	}
+	return nil
+}
+
+func TestSSE(t *testing.T) {
+	rw := httptest.NewRecorder()
+	Action((*SSEController).Watch).ServeHTTP(rw, httptest.NewRequest("GET", "/", nil))
+	equals(t, http.StatusOK, rw.Code)
+	equals(t, "text/event-stream", rw.Header().Get("Content-Type"))
+	equals(t, "data: hello\ndata: world\n\nevent: user\ndata: {\"id\":1}\n\n", rw.Body.String())
+	assert(t, rw.Flushed, "expected the events to be flushed\n")
+
+	defer func(d time.Duration) { SSEKeepAlive = d }(SSEKeepAlive)
+	SSEKeepAlive = 10 * time.Millisecond
+	rw = httptest.NewRecorder()
+	Action((*SSEController).Watch).ServeHTTP(rw, httptest.NewRequest("GET", "/?wait=1", nil))
+	assert(t, strings.Contains(rw.Body.String(), ": keep-alive\n\n"), "expected keep-alives, got %q\n", rw.Body.String())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	rw = httptest.NewRecorder()
+	Action((*SSEController).Disconnected).ServeHTTP(rw, httptest.NewRequest("GET", "/", nil).WithContext(ctx))
+	equals(t, "", rw.Body.String())
+}
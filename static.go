@@ -0,0 +1,128 @@
+package controller
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+)
+
+// StaticController serves static files through the controller lifecycle.
+// Embed it in a controller and configure it in Init:
+//
+//	type AssetsController struct {
+//		controller.StaticController
+//	}
+//
+//	func (c *AssetsController) Init(rw http.ResponseWriter, r *http.Request) error {
+//		c.FS = os.DirFS("public")
+//		c.MaxAge = 24 * time.Hour
+//		return c.StaticController.Init(rw, r)
+//	}
+//
+//	http.Handle("/assets/", http.StripPrefix("/assets", controller.Action((*AssetsController).Serve)))
+//
+// Missing files are reported as an HTTPError with code 404, so they go
+// through the Error method of the controller like any other error.
+type StaticController struct {
+	Base
+	// FS is the file system files are served from.
+	FS fs.FS
+	// MaxAge sets the max-age of the Cache-Control header. No Cache-Control
+	// header is sent if it is zero.
+	MaxAge time.Duration
+	// Fallback names a file that is served in place of files that do not
+	// exist, such as "index.html" for single page applications doing their own
+	// routing.
+	Fallback string
+}
+
+// Serve is an action serving the file named by the request path. Directories
+// are served by their index.html file. Paths are cleaned before use, so they
+// can not refer to files outside of FS.
+func (c *StaticController) Serve() error {
+	if c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead {
+		c.ResponseWriter.Header().Set("Allow", "GET, HEAD")
+		return &HTTPError{Code: http.StatusMethodNotAllowed}
+	}
+
+	name := strings.TrimPrefix(path.Clean("/"+c.Request.URL.Path), "/")
+	if name == "" {
+		name = "."
+	}
+
+	err := c.ServeFile(name)
+	if err != nil && c.Fallback != "" && errorCode(err) == http.StatusNotFound {
+		err = c.ServeFile(c.Fallback)
+	}
+	return err
+}
+
+// ServeFile serves the named file from FS, answering conditional and range
+// requests and setting the caching headers. Names are slash separated paths
+// as accepted by fs.FS.
+func (c *StaticController) ServeFile(name string) error {
+	if c.FS == nil || !fs.ValidPath(name) {
+		return &HTTPError{Code: http.StatusNotFound}
+	}
+
+	f, info, err := c.open(name)
+	if err != nil {
+		return fsError(err)
+	}
+	if info.IsDir() {
+		f.Close()
+		name = path.Join(name, "index.html")
+		if f, info, err = c.open(name); err != nil {
+			return fsError(err)
+		}
+	}
+	defer f.Close()
+
+	content, ok := f.(io.ReadSeeker)
+	if !ok {
+		data, err := io.ReadAll(f)
+		if err != nil {
+			return err
+		}
+		content = bytes.NewReader(data)
+	}
+
+	header := c.ResponseWriter.Header()
+	if c.MaxAge > 0 {
+		header.Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(c.MaxAge.Seconds())))
+	}
+	header.Set("ETag", fmt.Sprintf(`W/"%x-%x"`, info.Size(), info.ModTime().UnixNano()))
+
+	http.ServeContent(c.ResponseWriter, c.Request, name, info.ModTime(), content)
+	return nil
+}
+
+func (c *StaticController) open(name string) (fs.File, fs.FileInfo, error) {
+	f, err := c.FS.Open(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+	return f, info, nil
+}
+
+// fsError converts file system errors into their HTTP counterparts.
+func fsError(err error) error {
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return &HTTPError{Code: http.StatusNotFound, Err: err}
+	case errors.Is(err, fs.ErrPermission):
+		return &HTTPError{Code: http.StatusForbidden, Err: err}
+	}
+	return err
+}
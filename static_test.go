@@ -0,0 +1,62 @@
+package controller
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+type AssetsController struct {
+	StaticController
+}
+
+func (c *AssetsController) Init(rw http.ResponseWriter, r *http.Request) error {
+	c.FS = fstest.MapFS{
+		"index.html":      {Data: []byte("<app>")},
+		"css/site.css":    {Data: []byte("body {}")},
+		"docs/index.html": {Data: []byte("docs")},
+	}
+	c.MaxAge = time.Hour
+	c.Fallback = r.URL.Query().Get("fallback")
+	return c.StaticController.Init(rw, r)
+}
+
+func TestStaticController(t *testing.T) {
+	h := Action((*AssetsController).Serve)
+
+	var tests = []struct {
+		method string
+		path   string
+		code   int
+		body   string
+	}{
+		{"GET", "/css/site.css", http.StatusOK, "body {}"},
+		{"GET", "/docs/", http.StatusOK, "docs"},
+		{"GET", "/../../etc/passwd", http.StatusNotFound, ""},
+		{"GET", "/missing.js", http.StatusNotFound, ""},
+		{"GET", "/users/1?fallback=index.html", http.StatusOK, "<app>"},
+		{"POST", "/css/site.css", http.StatusMethodNotAllowed, ""},
+	}
+
+	for _, test := range tests {
+		rw := httptest.NewRecorder()
+		h.ServeHTTP(rw, httptest.NewRequest(test.method, test.path, nil))
+		equals(t, test.code, rw.Code)
+		if test.body != "" {
+			equals(t, test.body, rw.Body.String())
+		}
+	}
+
+	rw := httptest.NewRecorder()
+	h.ServeHTTP(rw, httptest.NewRequest("GET", "/css/site.css", nil))
+	equals(t, "public, max-age=3600", rw.Header().Get("Cache-Control"))
+	equals(t, "text/css; charset=utf-8", rw.Header().Get("Content-Type"))
+
+	r := httptest.NewRequest("GET", "/css/site.css", nil)
+	r.Header.Set("If-None-Match", rw.Header().Get("ETag"))
+	rw = httptest.NewRecorder()
+	h.ServeHTTP(rw, r)
+	equals(t, http.StatusNotModified, rw.Code)
+}
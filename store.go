@@ -0,0 +1,105 @@
+package controller
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Set stores value under key for the rest of the request, so that Init,
+// filters, mixins and actions can pass data such as the current user or
+// tenant to each other:
+//
+//	func (c *AppController) Init(rw http.ResponseWriter, r *http.Request) error {
+//		c.Base.Init(rw, r)
+//		user, err := authenticate(r)
+//		if err != nil {
+//			return err
+//		}
+//		c.Set("user", user)
+//		return nil
+//	}
+//
+// Unlike values added with WithValue, stored values are only visible to the
+// controller, not to the handlers and libraries the request is passed to.
+func (b *Base) Set(key string, value interface{}) {
+	if b.store == nil {
+		b.store = make(map[string]interface{})
+	}
+	b.store[key] = value
+}
+
+// Get returns the value stored under key with Set, and whether there is one.
+func (b *Base) Get(key string) (interface{}, bool) {
+	value, ok := b.store[key]
+	return value, ok
+}
+
+// Get returns the value of type T available to the controller c, and
+// whether there is one, sparing actions the type assertions of Base.Get:
+//
+//	user, ok := controller.Get[*User](c)
+//
+// The value is looked up among the values stored with Base.Set, then among
+// the values provided to the Injector or App of the action, or registered
+// with Provide. A value of type T is only found if it is the only one of
+// its kind, which matters when T is an interface type. Request scoped
+// values are not found, they have to be injected.
+func Get[T any](c Controller) (T, bool) {
+	var zero T
+	b, ok := c.(baser)
+	if !ok {
+		return zero, false
+	}
+	var (
+		found T
+		n     int
+	)
+	for _, value := range b.base().store {
+		if v, ok := value.(T); ok {
+			found, n = v, n+1
+		}
+	}
+	if n == 1 {
+		return found, true
+	}
+	if n > 1 {
+		return zero, false
+	}
+
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	var value reflect.Value
+	var err error
+	o, _ := b.base().Context().Value(optionsKey{}).(*options)
+	switch {
+	case o != nil && o.injector != nil:
+		value, err = resolve(o.injector, t)
+	case o != nil && o.app != nil:
+		value, _, err = o.app.providers.get(t, nil)
+	default:
+		value, _, err = providers.get(t, nil)
+	}
+	if err != nil {
+		return zero, false
+	}
+	return value.Interface().(T), true
+}
+
+// MustGet is like Get, but panics if no value of type T is available, for
+// values the application can not run without.
+func MustGet[T any](c Controller) T {
+	v, ok := Get[T](c)
+	if !ok {
+		panic(fmt.Sprintf("controller: no %s available", reflect.TypeOf((*T)(nil)).Elem()))
+	}
+	return v
+}
+
+// baser is implemented by the controllers embedding Base.
+type baser interface {
+	base() *Base
+}
+
+// base returns b. It gives access to the Base embedded in controllers.
+func (b *Base) base() *Base {
+	return b
+}
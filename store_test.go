@@ -0,0 +1,58 @@
+package controller
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestStore(t *testing.T) {
+	c := &Base{}
+	_, ok := c.Get("user")
+	assert(t, !ok, "expected no value for user\n")
+
+	c.Set("user", "gopher")
+	c.Set("tenant", 42)
+	value, ok := c.Get("user")
+	assert(t, ok, "expected a value for user\n")
+	equals(t, "gopher", value)
+	value, _ = c.Get("tenant")
+	equals(t, 42, value)
+
+	c.Set("user", nil)
+	value, ok = c.Get("user")
+	assert(t, ok, "expected a nil value for user\n")
+	equals(t, nil, value)
+}
+
+type GetController struct {
+	Base
+}
+
+func (c *GetController) Index() error {
+	c.Set("user", "gopher")
+	config := MustGet[*injectConfig](c)
+	user, _ := Get[string](c)
+	_, ok := Get[int](c)
+	return c.Textf(http.StatusOK, "%s %s %t", user, config.Env, ok)
+}
+
+func TestGet(t *testing.T) {
+	app := &App{}
+	app.Provide(&injectConfig{Env: "test"})
+	rw := httptest.NewRecorder()
+	app.Action((*GetController).Index).ServeHTTP(rw, httptest.NewRequest("GET", "/", nil))
+	equals(t, http.StatusOK, rw.Code)
+	equals(t, "gopher test false", rw.Body.String())
+
+	c := &GetController{}
+	c.Set("a", "x")
+	c.Set("b", "y")
+	_, ok := Get[string](c)
+	assert(t, !ok, "expected ambiguous values not to be found\n")
+
+	defer func() {
+		assert(t, recover() != nil, "expected MustGet to panic\n")
+	}()
+	MustGet[*injectConfig](c)
+}
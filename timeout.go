@@ -0,0 +1,56 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Timeout sets a deadline of d on the context of the requests served by an
+// action:
+//
+//	controller.Action((*ReportController).Show, controller.Timeout(5*time.Second))
+//
+// The timeout is cooperative. Once d has elapsed, the context is canceled,
+// which stops the queries, outgoing requests and other operations taking
+// it, and the functions registered with Base.Defer are called early, as
+// with AbortOnCancel, to release actions waiting for subscriptions. The
+// response is only written once the action returns: if the deadline passed
+// before, the response the action wrote is replaced with 503 Service
+// Unavailable, reported like the errors of actions, unless it has been sent
+// already or the action returned an HTTPError, such as one with 504 Gateway
+// Timeout for an upstream timeout. Finish and Destroy are called as for any
+// other request.
+//
+// Go can not stop an action from the outside, so the response time is not
+// bounded: an action that ignores the context of the request keeps the
+// connection until it returns. Wrap the handler in http.TimeoutHandler, or
+// set the timeouts of http.Server, to bound it regardless.
+func Timeout(d time.Duration) Option {
+	return func(o *options) {
+		o.timeout = d
+	}
+}
+
+// withTimeout returns a copy of r whose context is canceled once the
+// timeout of the action has elapsed.
+func (o *options) withTimeout(r *http.Request) (*http.Request, context.CancelFunc) {
+	ctx, cancel := context.WithTimeoutCause(r.Context(), o.timeout, fmt.Errorf("Action timed out after %s", o.timeout))
+	return r.WithContext(ctx), cancel
+}
+
+// timedOut returns the error to report for an action that returned err
+// while serving r with a timeout.
+func timedOut(r *http.Request, w *ResponseWriter, err error) error {
+	ctx := r.Context()
+	if w.Committed() || !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return err
+	}
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		return err
+	}
+	return &HTTPError{Code: http.StatusServiceUnavailable, Err: context.Cause(ctx)}
+}
@@ -0,0 +1,63 @@
+package controller
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type TimeoutController struct {
+	Base
+	destroyed *bool
+}
+
+func (c *TimeoutController) Destroy() {
+	*c.destroyed = true
+}
+
+func (c *TimeoutController) Slow() error {
+	c.Text(http.StatusOK, "partial")
+	<-c.Context().Done()
+	return c.Context().Err()
+}
+
+func (c *TimeoutController) Upstream() error {
+	<-c.Context().Done()
+	return &HTTPError{Code: http.StatusGatewayTimeout}
+}
+
+// Subscribe blocks until the subscription it registers with Defer is closed.
+func (c *TimeoutController) Subscribe() error {
+	sub := make(chan struct{})
+	c.Defer(func() { close(sub) })
+	<-sub
+	return nil
+}
+
+func (c *TimeoutController) Fast() error {
+	return c.Text(http.StatusOK, "fast")
+}
+
+func TestTimeout(t *testing.T) {
+	var tests = []struct {
+		action interface{}
+		code   int
+		body   string
+	}{
+		{(*TimeoutController).Slow, http.StatusServiceUnavailable, "Action timed out after 10ms\n"},
+		{(*TimeoutController).Upstream, http.StatusGatewayTimeout, "Gateway Timeout\n"},
+		{(*TimeoutController).Subscribe, http.StatusServiceUnavailable, "Action timed out after 10ms\n"},
+		{(*TimeoutController).Fast, http.StatusOK, "fast"},
+	}
+
+	for _, test := range tests {
+		destroyed := false
+		factory := Factory(func() Controller { return &TimeoutController{destroyed: &destroyed} })
+		rw := httptest.NewRecorder()
+		Action(test.action, Timeout(10*time.Millisecond), factory).ServeHTTP(rw, httptest.NewRequest("GET", "/", nil))
+		equals(t, test.code, rw.Code)
+		equals(t, test.body, rw.Body.String())
+		assert(t, destroyed, "expected Destroy to be called\n")
+	}
+}
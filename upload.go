@@ -0,0 +1,86 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// defaultMaxMemory is the default for Base.MaxMultipartMemory.
+const defaultMaxMemory = 32 << 20
+
+// FormFile returns the header of the first file uploaded for the given form
+// field of a multipart request, parsing the request body with the limits
+// configured on Base if needed. It fails with an HTTPError with code 400 if
+// the request carries no such file:
+//
+//	func (c *AvatarController) Create() error {
+//		id, err := c.ParamInt("id")
+//		if err != nil {
+//			return err
+//		}
+//		fh, err := c.FormFile("avatar")
+//		if err != nil {
+//			return err
+//		}
+//		return c.SaveUploadedFile(fh, filepath.Join("uploads", strconv.Itoa(id)+".png"))
+//	}
+func (b *Base) FormFile(name string) (*multipart.FileHeader, error) {
+	if err := parseMultipartForm(b.Request, b.MaxMultipartMemory, b.MaxUploadBytes); err != nil {
+		return nil, formError(err)
+	}
+	if files := b.Request.MultipartForm.File[name]; len(files) > 0 {
+		return files[0], nil
+	}
+	return nil, &HTTPError{Code: http.StatusBadRequest, Err: fmt.Errorf("Missing file %q", name)}
+}
+
+// SaveUploadedFile writes the content of an uploaded file to dst, creating
+// the directories leading up to it as needed. dst must not be built from
+// unchecked client input such as the file name of the upload or a raw
+// parameter, which could contain ".." elements leading out of the intended
+// directory.
+func (b *Base) SaveUploadedFile(fh *multipart.FileHeader, dst string) error {
+	src, err := fh.Open()
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0750); err != nil {
+		return err
+	}
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, src); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// parseMultipartForm parses the multipart body of r, keeping up to maxMemory
+// bytes in memory and reading at most maxBytes bytes if it is positive. Zero
+// values select the defaults. Parsing an already parsed form is a no-op.
+func parseMultipartForm(r *http.Request, maxMemory, maxBytes int64) error {
+	if r.MultipartForm != nil {
+		return nil
+	}
+	if maxMemory <= 0 {
+		maxMemory = defaultMaxMemory
+	}
+	if maxBytes > 0 {
+		r.Body = http.MaxBytesReader(nil, r.Body, maxBytes)
+	}
+	err := r.ParseMultipartForm(maxMemory)
+	if errors.Is(err, http.ErrNotMultipart) {
+		return &HTTPError{Code: http.StatusUnsupportedMediaType, Err: err}
+	}
+	return err
+}
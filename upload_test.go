@@ -0,0 +1,53 @@
+package controller
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func uploadRequest(t *testing.T, field, content string) *http.Request {
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	fw, err := w.CreateFormFile(field, "report.txt")
+	ok(t, err)
+	fw.Write([]byte(content))
+	ok(t, w.Close())
+
+	r := httptest.NewRequest("POST", "/", &body)
+	r.Header.Set("Content-Type", w.FormDataContentType())
+	return r
+}
+
+func TestFormFile(t *testing.T) {
+	c := &Base{Request: uploadRequest(t, "report", "quarterly numbers")}
+
+	fh, err := c.FormFile("report")
+	ok(t, err)
+	equals(t, "report.txt", fh.Filename)
+
+	dst := filepath.Join(t.TempDir(), "reports", "q1.txt")
+	ok(t, c.SaveUploadedFile(fh, dst))
+	data, err := os.ReadFile(dst)
+	ok(t, err)
+	equals(t, "quarterly numbers", string(data))
+
+	_, err = c.FormFile("missing")
+	equals(t, http.StatusBadRequest, errorCode(err))
+}
+
+func TestFormFileLimits(t *testing.T) {
+	c := &Base{Request: uploadRequest(t, "report", strings.Repeat("a", 1024)), MaxUploadBytes: 512}
+	_, err := c.FormFile("report")
+	equals(t, http.StatusRequestEntityTooLarge, errorCode(err))
+
+	c = &Base{Request: httptest.NewRequest("POST", "/", strings.NewReader("a=b"))}
+	c.Request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	_, err = c.FormFile("report")
+	equals(t, http.StatusUnsupportedMediaType, errorCode(err))
+}
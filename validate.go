@@ -0,0 +1,242 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+	"net/mail"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Validator validates values after they have been bound from a request.
+type Validator interface {
+	// Validate returns an error describing why v is invalid, or nil. Errors of
+	// type ValidationErrors are reported with 422 Unprocessable Entity.
+	Validate(v interface{}) error
+}
+
+// DefaultValidator is run by the binders on every value they bind. Replace it
+// to plug in another validation library, or set it to nil to disable
+// validation.
+var DefaultValidator Validator = TagValidator{}
+
+// FieldError describes a field that failed validation.
+type FieldError struct {
+	// Field is the path of the field, using the names it is bound by, such as
+	// "address.city".
+	Field string `json:"field"`
+	// Rule is the validation rule the field violates.
+	Rule string `json:"rule"`
+	// Message describes the violation.
+	Message string `json:"message"`
+}
+
+// ValidationErrors lists the fields of a value that failed validation.
+type ValidationErrors []FieldError
+
+// Error lists the violations one field per line.
+func (e ValidationErrors) Error() string {
+	lines := make([]string, len(e))
+	for i, fe := range e {
+		lines[i] = fe.Field + ": " + fe.Message
+	}
+	return "Validation failed:\n" + strings.Join(lines, "\n")
+}
+
+// TagValidator validates structs according to the `validate` tags of their
+// fields, which hold a comma separated list of rules:
+//
+//	type Signup struct {
+//		Email string `json:"email" validate:"required,email"`
+//		Name  string `json:"name" validate:"min=2,max=64"`
+//		Plan  string `json:"plan" validate:"oneof=free pro"`
+//		Age   int    `json:"age" validate:"min=18"`
+//	}
+//
+// The supported rules are:
+//
+//   - required: the field must not be zero,
+//   - min=n and max=n: bounds for numbers, and for the length of strings,
+//     slices and maps,
+//   - len=n: the exact length of strings, slices and maps,
+//   - oneof=a b c: the field must be one of the space separated values,
+//   - email: the field must be an e-mail address.
+//
+// Rules other than required are not checked for zero values, so optional
+// fields are only validated when they are set. Nested structs are validated
+// recursively.
+type TagValidator struct{}
+
+// Validate validates the struct v (or a pointer to it) points to. It returns
+// ValidationErrors listing every violation.
+func (TagValidator) Validate(v interface{}) error {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var errs ValidationErrors
+	if err := validateStruct(rv, "", &errs); err != nil {
+		return err
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func validateStruct(v reflect.Value, prefix string, errs *ValidationErrors) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		fv := v.Field(i)
+		name := prefix + fieldName(field)
+
+		if tag := field.Tag.Get("validate"); tag != "" && tag != "-" {
+			// Cut rather than Split the rules, so that validating a value
+			// does not allocate unless it violates them.
+			for rest, more := tag, true; more; {
+				var rule string
+				rule, rest, more = strings.Cut(rest, ",")
+				msg, err := checkRule(fv, rule)
+				if err != nil {
+					return fmt.Errorf("Field %s: %w", name, err)
+				}
+				if msg != "" {
+					*errs = append(*errs, FieldError{Field: name, Rule: rule, Message: msg})
+				}
+			}
+		}
+
+		for fv.Kind() == reflect.Ptr && !fv.IsNil() {
+			fv = fv.Elem()
+		}
+		if fv.Kind() == reflect.Struct && isStruct(fv.Type()) {
+			if field.Anonymous {
+				name = strings.TrimSuffix(prefix, ".")
+			}
+			if err := validateStruct(fv, name+".", errs); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// fieldName returns the name a field is bound by.
+func fieldName(field reflect.StructField) string {
+	for _, tag := range []string{"json", "form", "query", "xml"} {
+		if name, _, _ := strings.Cut(field.Tag.Get(tag), ","); name != "" && name != "-" {
+			return name
+		}
+	}
+	return field.Name
+}
+
+// checkRule checks v against rule, returning a message describing the
+// violation or an empty string if v satisfies the rule.
+func checkRule(v reflect.Value, rule string) (string, error) {
+	name, arg, _ := strings.Cut(rule, "=")
+	if name == "required" {
+		if v.IsZero() || hasLen(v) && v.Len() == 0 {
+			return "is required", nil
+		}
+		return "", nil
+	}
+	if v.IsZero() {
+		return "", nil
+	}
+	for v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+
+	switch name {
+	case "min", "max", "len":
+		bound, err := strconv.ParseFloat(arg, 64)
+		if err != nil {
+			return "", fmt.Errorf("Invalid argument for rule %q", rule)
+		}
+		n, isLen, ok := measure(v)
+		if !ok {
+			return "", fmt.Errorf("Rule %q does not apply to %s", rule, v.Type())
+		}
+		switch {
+		case name == "min" && n < bound && isLen:
+			return fmt.Sprintf("must be at least %s long", arg), nil
+		case name == "min" && n < bound:
+			return fmt.Sprintf("must be at least %s", arg), nil
+		case name == "max" && n > bound && isLen:
+			return fmt.Sprintf("must be at most %s long", arg), nil
+		case name == "max" && n > bound:
+			return fmt.Sprintf("must be at most %s", arg), nil
+		case name == "len" && n != bound:
+			return fmt.Sprintf("must be exactly %s long", arg), nil
+		}
+	case "oneof":
+		s := fmt.Sprint(v.Interface())
+		for _, option := range strings.Fields(arg) {
+			if s == option {
+				return "", nil
+			}
+		}
+		return "must be one of " + strings.Join(strings.Fields(arg), ", "), nil
+	case "email":
+		addr, err := mail.ParseAddress(v.String())
+		if v.Kind() != reflect.String || err != nil || addr.Address != v.String() {
+			return "must be an e-mail address", nil
+		}
+	default:
+		return "", fmt.Errorf("Unknown validation rule %q", rule)
+	}
+	return "", nil
+}
+
+// measure returns the number min and max compare to, and whether it is a
+// length rather than a value.
+func measure(v reflect.Value) (n float64, isLen, ok bool) {
+	switch v.Kind() {
+	case reflect.String:
+		return float64(utf8.RuneCountInString(v.String())), true, true
+	case reflect.Slice, reflect.Map, reflect.Array:
+		return float64(v.Len()), true, true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), false, true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), false, true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), false, true
+	}
+	return 0, false, false
+}
+
+func hasLen(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Slice, reflect.Map:
+		return true
+	}
+	return false
+}
+
+// validate runs DefaultValidator on a bound value, reporting violations with
+// 422 Unprocessable Entity.
+func validate(v interface{}) error {
+	if DefaultValidator == nil {
+		return nil
+	}
+	err := DefaultValidator.Validate(v)
+	if _, ok := err.(ValidationErrors); ok {
+		return &HTTPError{Code: http.StatusUnprocessableEntity, Err: err}
+	}
+	return err
+}
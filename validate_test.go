@@ -0,0 +1,111 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type validateAddress struct {
+	City string `json:"city" validate:"required"`
+}
+
+type validateSignup struct {
+	Email   string           `json:"email" validate:"required,email"`
+	Name    string           `json:"name" validate:"min=2,max=5"`
+	Plan    string           `json:"plan" validate:"oneof=free pro"`
+	Age     int              `json:"age" validate:"min=18"`
+	Tags    []string         `json:"tags" validate:"max=2"`
+	Code    string           `json:"code" validate:"len=4"`
+	Address *validateAddress `json:"address"`
+}
+
+func TestTagValidator(t *testing.T) {
+	var tests = []struct {
+		value interface{}
+		rules []string
+	}{
+		{validateSignup{Email: "gopher@example.com"}, nil},
+		{&validateSignup{Email: "gopher@example.com", Name: "Gus", Plan: "pro", Age: 30, Code: "abcd"}, nil},
+		{validateSignup{}, []string{"email:required"}},
+		{validateSignup{Email: "gopher"}, []string{"email:email"}},
+		{validateSignup{Email: "gopher@example.com", Name: "G", Plan: "gold", Age: 12}, []string{"name:min=2", "plan:oneof=free pro", "age:min=18"}},
+		{validateSignup{Email: "gopher@example.com", Name: "Gopher", Tags: []string{"a", "b", "c"}, Code: "abc"}, []string{"name:max=5", "tags:max=2", "code:len=4"}},
+		{validateSignup{Email: "gopher@example.com", Address: &validateAddress{}}, []string{"address.city:required"}},
+		{"not a struct", nil},
+	}
+
+	for _, test := range tests {
+		err := TagValidator{}.Validate(test.value)
+		if test.rules == nil {
+			ok(t, err)
+			continue
+		}
+		errs, isValidation := err.(ValidationErrors)
+		assert(t, isValidation, "expected ValidationErrors for %+v, got %v\n", test.value, err)
+		var rules []string
+		for _, fe := range errs {
+			rules = append(rules, fe.Field+":"+fe.Rule)
+		}
+		equals(t, test.rules, rules)
+	}
+
+	err := TagValidator{}.Validate(struct {
+		Name string `validate:"shiny"`
+	}{"x"})
+	assert(t, err != nil, "expected an error for an unknown rule\n")
+}
+
+func TestBindValidates(t *testing.T) {
+	r := httptest.NewRequest("POST", "/", strings.NewReader(`{"email": "gopher", "age": 3}`))
+	r.Header.Set("Content-Type", "application/json")
+
+	var signup validateSignup
+	err := (&Base{Request: r}).BindJSON(&signup)
+	equals(t, http.StatusUnprocessableEntity, errorCode(err))
+	equals(t, "Validation failed:\nemail: must be an e-mail address\nage: must be at least 18", err.Error())
+}
+
+type ValidateController struct {
+	Base
+}
+
+func (c *ValidateController) HandleError(err error) {
+	var fields ValidationErrors
+	if errors.As(err, &fields) {
+		c.JSON(ErrorStatus(err), map[string]interface{}{"errors": fields})
+		return
+	}
+	c.Error(ErrorStatus(err), err.Error())
+}
+
+func (c *ValidateController) Create(in *validateSignup) error {
+	return c.NoContent()
+}
+
+func TestErrorHandler(t *testing.T) {
+	h := Action((*ValidateController).Create)
+
+	r := httptest.NewRequest("POST", "/", strings.NewReader(`{"email": "gopher", "age": 3}`))
+	r.Header.Set("Content-Type", "application/json")
+	rw := httptest.NewRecorder()
+	h.ServeHTTP(rw, r)
+	equals(t, http.StatusUnprocessableEntity, rw.Code)
+	var body struct {
+		Errors []FieldError `json:"errors"`
+	}
+	ok(t, json.Unmarshal(rw.Body.Bytes(), &body))
+	equals(t, []FieldError{
+		{Field: "email", Rule: "email", Message: "must be an e-mail address"},
+		{Field: "age", Rule: "min=18", Message: "must be at least 18"},
+	}, body.Errors)
+
+	r = httptest.NewRequest("POST", "/", strings.NewReader(`{`))
+	r.Header.Set("Content-Type", "application/json")
+	rw = httptest.NewRecorder()
+	h.ServeHTTP(rw, r)
+	equals(t, http.StatusBadRequest, rw.Code)
+}
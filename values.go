@@ -0,0 +1,261 @@
+package controller
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// timeLayouts are the layouts tried in order when binding time.Time values.
+// They cover RFC 3339 as well as the formats sent by HTML date and time
+// inputs.
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02",
+}
+
+var (
+	timeType            = reflect.TypeOf(time.Time{})
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// decodeValues populates the struct dst points to from values. Fields are
+// matched by the name given in the struct tag named by tag, or by their Go
+// name if they have none, and skipped if the tag is "-". Fields of nested
+// structs are matched with the name of the struct field as prefix, as in
+// "address.city", while embedded structs are flattened into their parent.
+//
+// Slices are filled with all values of their key, which may also be
+// suffixed with "[]". Besides the basic kinds, time.Time and implementations
+// of encoding.TextUnmarshaler are supported. Fields without a value are left
+// untouched.
+func decodeValues(values url.Values, dst interface{}, tag string) error {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("Binding target must be a pointer to a struct, got %T", dst)
+	}
+	return decodeStruct(values, v.Elem(), tag, "")
+}
+
+func decodeStruct(values url.Values, v reflect.Value, tag, prefix string) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, tagged := field.Tag.Lookup(tag)
+		if name == "-" {
+			continue
+		}
+		if name, _, _ = strings.Cut(name, ","); name == "" {
+			name = field.Name
+		}
+
+		fv := v.Field(i)
+		if field.Anonymous && !tagged && indirectType(field.Type).Kind() == reflect.Struct {
+			if err := decodeStruct(values, allocate(fv), tag, prefix); err != nil {
+				return err
+			}
+			continue
+		}
+
+		key := prefix + name
+		if isStruct(field.Type) {
+			if hasPrefix(values, key+".") {
+				if err := decodeStruct(values, allocate(fv), tag, key+"."); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		vals, ok := values[key]
+		if !ok {
+			vals, ok = values[key+"[]"]
+		}
+		if !ok || len(vals) == 0 {
+			continue
+		}
+		if err := setField(fv, vals); err != nil {
+			return &HTTPError{
+				Code: http.StatusUnprocessableEntity,
+				Err:  fmt.Errorf("Invalid value for %q: %v", key, err),
+			}
+		}
+	}
+	return nil
+}
+
+// setDefaults sets the zero fields of the struct dst points to to the value
+// of their `default` tag, recursing into nested structs. Defaults of slices
+// are comma separated lists. Non-struct values are left alone.
+func setDefaults(dst interface{}) error {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return nil
+	}
+	return setStructDefaults(v.Elem())
+}
+
+func setStructDefaults(v reflect.Value) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		fv := v.Field(i)
+
+		if def, ok := field.Tag.Lookup("default"); ok && fv.IsZero() {
+			vals := []string{def}
+			if fv.Kind() == reflect.Slice {
+				vals = strings.Split(def, ",")
+			}
+			if err := setField(fv, vals); err != nil {
+				return fmt.Errorf("Invalid default for field %s: %v", field.Name, err)
+			}
+			continue
+		}
+
+		if fv.Kind() == reflect.Ptr && fv.IsNil() {
+			continue
+		}
+		if isStruct(field.Type) {
+			if err := setStructDefaults(allocate(fv)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// setField sets v from the given values, of which only the first is used
+// unless v is a slice.
+func setField(v reflect.Value, vals []string) error {
+	if v.Kind() == reflect.Slice && !implementsText(v.Type()) {
+		s := reflect.MakeSlice(v.Type(), len(vals), len(vals))
+		for i, val := range vals {
+			if err := setValue(s.Index(i), val); err != nil {
+				return err
+			}
+		}
+		v.Set(s)
+		return nil
+	}
+	return setValue(v, vals[0])
+}
+
+// setValue parses s into v.
+func setValue(v reflect.Value, s string) error {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return setValue(v.Elem(), s)
+	}
+
+	if v.Type() == timeType {
+		for _, layout := range timeLayouts {
+			if t, err := time.Parse(layout, s); err == nil {
+				v.Set(reflect.ValueOf(t))
+				return nil
+			}
+		}
+		return fmt.Errorf("%q is not a valid time", s)
+	}
+	if v.CanAddr() && implementsText(v.Type()) {
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			// Checkboxes are submitted as "on" by browsers.
+			if s != "on" {
+				return fmt.Errorf("%q is not a boolean", s)
+			}
+			b = true
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if v.Type() == reflect.TypeOf(time.Duration(0)) {
+			d, err := time.ParseDuration(s)
+			if err != nil {
+				return fmt.Errorf("%q is not a duration", s)
+			}
+			v.SetInt(int64(d))
+			return nil
+		}
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("%q is not an integer", s)
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("%q is not an unsigned integer", s)
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("%q is not a number", s)
+		}
+		v.SetFloat(n)
+	default:
+		return errors.New("unsupported field type " + v.Type().String())
+	}
+	return nil
+}
+
+// allocate returns the struct v holds, allocating it if v is a nil pointer.
+func allocate(v reflect.Value) reflect.Value {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+func indirectType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+// isStruct reports whether t is a (pointer to a) struct that is bound field by
+// field rather than from a single value.
+func isStruct(t reflect.Type) bool {
+	t = indirectType(t)
+	return t.Kind() == reflect.Struct && t != timeType && !implementsText(t)
+}
+
+func implementsText(t reflect.Type) bool {
+	return reflect.PtrTo(t).Implements(textUnmarshalerType)
+}
+
+func hasPrefix(values url.Values, prefix string) bool {
+	for key := range values {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
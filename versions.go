@@ -0,0 +1,218 @@
+package controller
+
+import (
+	"context"
+	"mime"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Versions routes API requests to the mux of the version they ask for. Each
+// version gets its own http.ServeMux, usually with controllers mounted on it:
+//
+//	v1, v2 := http.NewServeMux(), http.NewServeMux()
+//	controller.Mount(v1, "/", (*UsersV1)(nil))
+//	controller.Mount(v2, "/", (*UsersV2)(nil))
+//
+//	api := &controller.Versions{Header: "API-Version"}
+//	api.Handle("v1", v1)
+//	api.Handle("v2", v2)
+//
+// The version of a request is taken from, in order of precedence:
+//
+//  1. a path prefix naming a registered version ("/v2/users"), which is
+//     stripped before the request is routed,
+//  2. the custom request header named by Header, if set,
+//  3. the Accept header, either as a version parameter
+//     ("application/json; version=2") or as a vendor media type
+//     ("application/vnd.example.v2+json"),
+//  4. the Default version, or the latest registered version.
+//
+// Versions form a fallback chain: a route that is not found in the requested
+// version is looked up in the previously registered versions, newest first,
+// and finally in the mux registered for the empty version. This way actions
+// that did not change are shared between versions instead of duplicated.
+//
+// The selected version is available to controllers through Base.APIVersion.
+type Versions struct {
+	// Header is the name of a custom request header that selects the version,
+	// such as "API-Version". It is ignored if empty.
+	Header string
+	// Default is the version used for requests that do not ask for one. It
+	// defaults to the latest registered version.
+	Default string
+
+	names  []string
+	muxes  map[string]*http.ServeMux
+	shared *http.ServeMux
+}
+
+// Handle registers the mux serving the given version. Versions have to be
+// registered from oldest to newest. The empty version registers the mux
+// shared by all versions.
+func (v *Versions) Handle(version string, mux *http.ServeMux) {
+	if version == "" {
+		v.shared = mux
+		return
+	}
+	if v.muxes == nil {
+		v.muxes = make(map[string]*http.ServeMux)
+	}
+	key := normalizeVersion(version)
+	if _, ok := v.muxes[key]; !ok {
+		v.names = append(v.names, key)
+	}
+	v.muxes[key] = mux
+}
+
+// ServeHTTP routes the request to the mux of the requested version.
+func (v *Versions) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	version, r, ok := v.version(r)
+	if !ok {
+		http.Error(rw, "Unsupported API version", http.StatusBadRequest)
+		return
+	}
+
+	ctx := context.WithValue(r.Context(), versionKey{}, version)
+	r = r.WithContext(ctx)
+
+	chain := v.chain(version)
+	for _, mux := range chain {
+		if _, pattern := mux.Handler(r); pattern != "" {
+			mux.ServeHTTP(rw, r)
+			return
+		}
+	}
+
+	// If the path is routed for other methods somewhere in the chain, answer
+	// with 405 Method Not Allowed and the methods of the whole chain.
+	var allow []string
+	for _, mux := range chain {
+		for _, method := range allowedMethods(mux, r) {
+			if !contains(allow, method) {
+				allow = append(allow, method)
+			}
+		}
+	}
+	if allow == nil {
+		http.NotFound(rw, r)
+		return
+	}
+	sort.Strings(allow)
+	rw.Header().Set("Allow", strings.Join(allow, ", "))
+	http.Error(rw, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+}
+
+// allowedMethods returns the methods mux routes the path of r for, as
+// reported in the Allow header of its 405 response, given that mux has no
+// route for the method of r.
+func allowedMethods(mux *http.ServeMux, r *http.Request) []string {
+	rec := &allowRecorder{header: make(http.Header)}
+	mux.ServeHTTP(rec, r)
+	if rec.code != http.StatusMethodNotAllowed {
+		return nil
+	}
+	var methods []string
+	for _, method := range strings.Split(rec.header.Get("Allow"), ",") {
+		if method = strings.TrimSpace(method); method != "" {
+			methods = append(methods, method)
+		}
+	}
+	return methods
+}
+
+// allowRecorder records the status code and headers of the responses mux
+// sends for routes it does not have, discarding their bodies.
+type allowRecorder struct {
+	header http.Header
+	code   int
+}
+
+func (w *allowRecorder) Header() http.Header         { return w.header }
+func (w *allowRecorder) Write(p []byte) (int, error) { return len(p), nil }
+func (w *allowRecorder) WriteHeader(code int)        { w.code = code }
+
+// version determines the version requested by r, returning the request with
+// the version prefix stripped from its path if the version came from there.
+// It reports false if r asks for a version that is not registered.
+func (v *Versions) version(r *http.Request) (string, *http.Request, bool) {
+	segment, rest, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	if key := normalizeVersion(segment); strings.EqualFold(key, segment) && v.muxes[key] != nil {
+		return key, withPath(r, "/"+rest), true
+	}
+
+	requested := ""
+	if v.Header != "" {
+		requested = r.Header.Get(v.Header)
+	}
+	if requested == "" {
+		requested = acceptVersion(r.Header.Get("Accept"))
+	}
+	if requested == "" {
+		requested = v.Default
+	}
+	if requested == "" {
+		if len(v.names) == 0 {
+			return "", r, true
+		}
+		return v.names[len(v.names)-1], r, true
+	}
+
+	key := normalizeVersion(requested)
+	return key, r, v.muxes[key] != nil
+}
+
+// chain returns the muxes to look up routes in for the given version.
+func (v *Versions) chain(version string) []*http.ServeMux {
+	var chain []*http.ServeMux
+	found := false
+	for i := len(v.names) - 1; i >= 0; i-- {
+		if v.names[i] == version {
+			found = true
+		}
+		if found {
+			chain = append(chain, v.muxes[v.names[i]])
+		}
+	}
+	if v.shared != nil {
+		chain = append(chain, v.shared)
+	}
+	return chain
+}
+
+var vendorVersion = regexp.MustCompile(`^application/vnd\.[^+]+\.(v[0-9][0-9a-z.]*)(\+|$)`)
+
+// acceptVersion extracts the version from the first media range in an Accept
+// header that specifies one.
+func acceptVersion(accept string) string {
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		if version := params["version"]; version != "" {
+			return version
+		}
+		if m := vendorVersion.FindStringSubmatch(mediaType); m != nil {
+			return m[1]
+		}
+	}
+	return ""
+}
+
+// normalizeVersion makes "v2", "V2" and "2" refer to the same version.
+func normalizeVersion(version string) string {
+	return "v" + strings.TrimPrefix(strings.ToLower(version), "v")
+}
+
+type versionKey struct{}
+
+// APIVersion returns the API version selected for the request by Versions,
+// normalized to the form "v2". It returns an empty string if the request was
+// not routed through Versions.
+func (b *Base) APIVersion() string {
+	version, _ := b.Request.Context().Value(versionKey{}).(string)
+	return version
+}
@@ -0,0 +1,94 @@
+package controller
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type VersionController struct {
+	Base
+}
+
+func (c *VersionController) Users() error {
+	c.ResponseWriter.Write([]byte(c.APIVersion() + " users"))
+	return nil
+}
+
+func (c *VersionController) Status() error {
+	c.ResponseWriter.Write([]byte(c.APIVersion() + " status"))
+	return nil
+}
+
+func TestVersions(t *testing.T) {
+	v1, v2, shared := http.NewServeMux(), http.NewServeMux(), http.NewServeMux()
+	v1.Handle("GET /users", Action((*VersionController).Users))
+	v1.Handle("GET /legacy", Action((*VersionController).Status))
+	v2.Handle("GET /users", Action((*VersionController).Users))
+	shared.Handle("GET /status", Action((*VersionController).Status))
+
+	api := &Versions{Header: "API-Version", Default: "v1"}
+	api.Handle("v1", v1)
+	api.Handle("v2", v2)
+	api.Handle("", shared)
+
+	var tests = []struct {
+		path   string
+		header string
+		accept string
+		code   int
+		body   string
+	}{
+		{"/users", "", "", http.StatusOK, "v1 users"},
+		{"/v2/users", "", "", http.StatusOK, "v2 users"},
+		{"/V2/users", "", "", http.StatusOK, "v2 users"},
+		{"/users", "2", "", http.StatusOK, "v2 users"},
+		{"/users", "", "application/json; version=2", http.StatusOK, "v2 users"},
+		{"/users", "", "application/vnd.example.v2+json", http.StatusOK, "v2 users"},
+		{"/v1/users", "2", "", http.StatusOK, "v1 users"},
+		{"/v2/legacy", "", "", http.StatusOK, "v2 status"},
+		{"/v2/status", "", "", http.StatusOK, "v2 status"},
+		{"/v2/missing", "", "", http.StatusNotFound, ""},
+		{"/users", "v9", "", http.StatusBadRequest, ""},
+	}
+
+	for _, test := range tests {
+		r := httptest.NewRequest("GET", test.path, nil)
+		if test.header != "" {
+			r.Header.Set("API-Version", test.header)
+		}
+		if test.accept != "" {
+			r.Header.Set("Accept", test.accept)
+		}
+		rw := httptest.NewRecorder()
+		api.ServeHTTP(rw, r)
+		equals(t, test.code, rw.Code)
+		if test.body != "" {
+			equals(t, test.body, rw.Body.String())
+		}
+	}
+}
+
+func TestVersionsMethodNotAllowed(t *testing.T) {
+	v1, v2 := http.NewServeMux(), http.NewServeMux()
+	v1.Handle("GET /legacy", Action((*VersionController).Status))
+	v1.Handle("DELETE /legacy", Action((*VersionController).Status))
+	v2.Handle("GET /users", Action((*VersionController).Users))
+	v2.Handle("PUT /legacy", Action((*VersionController).Status))
+	shared := http.NewServeMux()
+	shared.Handle("PURGE /legacy", Action((*VersionController).Status))
+
+	api := new(Versions)
+	api.Handle("v1", v1)
+	api.Handle("v2", v2)
+	api.Handle("", shared)
+
+	rw := httptest.NewRecorder()
+	api.ServeHTTP(rw, httptest.NewRequest("POST", "/v2/legacy", nil))
+	equals(t, http.StatusMethodNotAllowed, rw.Code)
+	equals(t, "DELETE, GET, HEAD, PURGE, PUT", rw.Header().Get("Allow"))
+
+	rw = httptest.NewRecorder()
+	api.ServeHTTP(rw, httptest.NewRequest("POST", "/v2/missing", nil))
+	equals(t, http.StatusNotFound, rw.Code)
+}
@@ -0,0 +1,215 @@
+package controller
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+)
+
+// ResponseBufferSize is the number of bytes of a response body that are
+// buffered by ResponseWriter before the response is sent to the client.
+var ResponseBufferSize = 32 << 10
+
+// ResponseWriter is the http.ResponseWriter handed to controllers by Action.
+// It records the status code and the size of the response, for after-action
+// logic and access logs:
+//
+//	func (c *UserController) Destroy() {
+//		if w, ok := c.ResponseWriter.(*controller.ResponseWriter); ok {
+//			log.Printf("%s %s %d %d", c.Request.Method, c.Request.URL, w.Status(), w.Size())
+//		}
+//	}
+//
+// The response is buffered until ResponseBufferSize bytes have been written
+// or it is flushed. When an action or Init returns an error, a response that
+// has not been sent yet is discarded, so that the Error method of the
+// controller replaces it instead of being appended to a partial body.
+//
+// ResponseWriters are recycled once the request has been served, so they
+// must not be used afterwards, for example by goroutines started by an
+// action.
+type ResponseWriter struct {
+	w      http.ResponseWriter
+	status int
+	size   int64
+	buf    bytes.Buffer
+	// header is a copy of the header taken when the ResponseWriter was
+	// created, which Discard restores.
+	header http.Header
+	// wroteHeader is set once the status code has been set explicitly.
+	// Otherwise the wrapped ResponseWriter picks the status of the response
+	// when the body is sent.
+	wroteHeader bool
+	committed   bool
+}
+
+var writerPool = sync.Pool{
+	New: func() interface{} { return &ResponseWriter{header: make(http.Header)} },
+}
+
+// newResponseWriter takes a ResponseWriter wrapping rw from the pool.
+func newResponseWriter(rw http.ResponseWriter) *ResponseWriter {
+	w := writerPool.Get().(*ResponseWriter)
+	w.w = rw
+	for key, values := range rw.Header() {
+		w.header[key] = values
+	}
+	return w
+}
+
+// release sends what is left of the response and returns w to the pool.
+func (w *ResponseWriter) release() {
+	w.commit()
+	w.w, w.status, w.size, w.wroteHeader, w.committed = nil, 0, 0, false, false
+	for key := range w.header {
+		delete(w.header, key)
+	}
+	if w.buf.Cap() > ResponseBufferSize {
+		w.buf = bytes.Buffer{}
+	}
+	w.buf.Reset()
+	writerPool.Put(w)
+}
+
+// Header returns the header map that will be sent with the response.
+func (w *ResponseWriter) Header() http.Header {
+	return w.w.Header()
+}
+
+// WriteHeader records the status code of the response. Informational 1xx
+// codes are sent right away, others with the body.
+func (w *ResponseWriter) WriteHeader(code int) {
+	if code >= 100 && code <= 199 && code != http.StatusSwitchingProtocols {
+		w.w.WriteHeader(code)
+		return
+	}
+	if w.status == 0 {
+		w.status, w.wroteHeader = code, true
+	}
+}
+
+// Write writes p to the body of the response, sending the status code 200
+// OK if none has been set.
+func (w *ResponseWriter) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	w.size += int64(len(p))
+	if !w.committed && w.buf.Len()+len(p) <= ResponseBufferSize {
+		return w.buf.Write(p)
+	}
+	if err := w.commit(); err != nil {
+		return 0, err
+	}
+	return w.w.Write(p)
+}
+
+// Status returns the status code of the response, or 0 if nothing has been
+// written yet.
+func (w *ResponseWriter) Status() int {
+	return w.status
+}
+
+// Size returns the number of bytes written to the body of the response.
+func (w *ResponseWriter) Size() int64 {
+	return w.size
+}
+
+// Written reports whether a status code or body has been written.
+func (w *ResponseWriter) Written() bool {
+	return w.status != 0
+}
+
+// Committed reports whether the response has been sent to the client, so
+// that it can no longer be discarded.
+func (w *ResponseWriter) Committed() bool {
+	return w.committed
+}
+
+// Discard drops the status code, body and headers written so far, and
+// reports whether it could do so. Responses that have been sent to the
+// client already can not be discarded.
+func (w *ResponseWriter) Discard() bool {
+	if w.committed {
+		return false
+	}
+	w.status, w.size, w.wroteHeader = 0, 0, false
+	w.buf.Reset()
+	h := w.w.Header()
+	for key := range h {
+		delete(h, key)
+	}
+	for key, values := range w.header {
+		h[key] = values
+	}
+	return true
+}
+
+// Flush sends the buffered response to the client.
+func (w *ResponseWriter) Flush() {
+	w.FlushError()
+}
+
+// FlushError is like Flush, but returns the error of the underlying
+// ResponseWriter. It is used by http.ResponseController.
+func (w *ResponseWriter) FlushError() error {
+	if err := w.commit(); err != nil {
+		return err
+	}
+	return http.NewResponseController(w.w).Flush()
+}
+
+// Hijack lets the caller take over the connection, as for http.Hijacker.
+// Buffered data is discarded.
+func (w *ResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := http.NewResponseController(w.w).Hijack()
+	if err == nil {
+		w.committed = true
+		w.buf.Reset()
+	}
+	return conn, rw, err
+}
+
+// Unwrap returns the wrapped ResponseWriter, for http.ResponseController.
+func (w *ResponseWriter) Unwrap() http.ResponseWriter {
+	return w.w
+}
+
+// commit sends the status code and the buffered body, if they have not been
+// sent yet.
+func (w *ResponseWriter) commit() error {
+	if w.committed || w.status == 0 {
+		return nil
+	}
+	w.committed = true
+	if w.wroteHeader {
+		w.w.WriteHeader(w.status)
+		if w.buf.Len() == 0 {
+			return nil
+		}
+	}
+	_, err := w.w.Write(w.buf.Bytes())
+	w.buf.Reset()
+	return err
+}
+
+// fail reports err through the HandleError method of c if it is an
+// ErrorHandler, or else its Error method, replacing the response written so
+// far if it has not been sent yet.
+func fail(c Controller, w *ResponseWriter, err error) {
+	w.Discard()
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		for key, values := range httpErr.Header {
+			w.Header()[key] = values
+		}
+	}
+	if h, ok := c.(ErrorHandler); ok {
+		h.HandleError(err)
+		return
+	}
+	c.Error(errorCode(err), err.Error())
+}
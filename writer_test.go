@@ -0,0 +1,76 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type WriterController struct {
+	Base
+}
+
+func (c *WriterController) Partial() error {
+	c.ResponseWriter.Header().Set("Content-Disposition", "attachment")
+	c.ResponseWriter.Write([]byte("id,name\n"))
+	return errors.New("database gone")
+}
+
+func (c *WriterController) Large() error {
+	c.ResponseWriter.Write([]byte(strings.Repeat("a", ResponseBufferSize+1)))
+	return errors.New("database gone")
+}
+
+func (c *WriterController) Created() error {
+	c.ResponseWriter.WriteHeader(http.StatusCreated)
+	c.ResponseWriter.Write([]byte("created"))
+	w := c.ResponseWriter.(*ResponseWriter)
+	c.ResponseWriter.Header().Set("X-Status", http.StatusText(w.Status()))
+	return nil
+}
+
+func TestResponseWriter(t *testing.T) {
+	// Errors replace responses that have not been sent yet.
+	rw := httptest.NewRecorder()
+	rw.Header().Set("X-Request-Id", "1")
+	Action((*WriterController).Partial).ServeHTTP(rw, httptest.NewRequest("GET", "/", nil))
+	equals(t, http.StatusInternalServerError, rw.Code)
+	equals(t, "database gone\n", rw.Body.String())
+	equals(t, "", rw.Header().Get("Content-Disposition"))
+	equals(t, "1", rw.Header().Get("X-Request-Id"))
+
+	// Errors are appended to responses that have been sent already.
+	rw = httptest.NewRecorder()
+	Action((*WriterController).Large).ServeHTTP(rw, httptest.NewRequest("GET", "/", nil))
+	equals(t, http.StatusOK, rw.Code)
+	equals(t, ResponseBufferSize+1+len("database gone\n"), rw.Body.Len())
+
+	// Headers can be set until the response is sent.
+	rw = httptest.NewRecorder()
+	Action((*WriterController).Created).ServeHTTP(rw, httptest.NewRequest("GET", "/", nil))
+	equals(t, http.StatusCreated, rw.Code)
+	equals(t, "created", rw.Body.String())
+	equals(t, "Created", rw.Header().Get("X-Status"))
+}
+
+func TestResponseWriterStatus(t *testing.T) {
+	rw := httptest.NewRecorder()
+	w := newResponseWriter(rw)
+	assert(t, !w.Written(), "expected nothing to be written\n")
+	w.Write([]byte("hello"))
+	w.WriteHeader(http.StatusTeapot)
+	equals(t, http.StatusOK, w.Status())
+	equals(t, int64(5), w.Size())
+	assert(t, !w.Committed(), "expected the response to be buffered\n")
+	equals(t, 0, rw.Body.Len())
+
+	w.Flush()
+	assert(t, w.Committed(), "expected the response to be sent\n")
+	assert(t, rw.Flushed, "expected the response to be flushed\n")
+	assert(t, !w.Discard(), "expected a sent response not to be discarded\n")
+	w.Write([]byte(", world"))
+	equals(t, "hello, world", rw.Body.String())
+	w.release()
+}
@@ -0,0 +1,228 @@
+// Package ws provides a controller base for WebSocket endpoints.
+//
+// WebSocket controllers embed Controller and implement the callbacks of
+// Handler they need. A controller is constructed, injected and initialized
+// for every connection, like for an HTTP request, and destroyed once the
+// connection is closed:
+//
+//	type ChatController struct {
+//		ws.Controller
+//		Rooms *chat.Rooms `inject:""`
+//	}
+//
+//	func (c *ChatController) OnMessage(messageType int, data []byte) error {
+//		return c.Rooms.Broadcast(c.Param("room"), data)
+//	}
+//
+//	mux.Handle("GET /rooms/{room}/ws", ws.Action[ChatController]())
+//
+// Connections are pinged every PingInterval, and closed if the client does
+// not answer in time, so that dead connections do not keep their
+// controllers alive.
+package ws
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/codegangsta/controller"
+	"github.com/gorilla/websocket"
+)
+
+// Handler is implemented by WebSocket controllers, which embed Controller.
+type Handler interface {
+	controller.Controller
+	// OnConnect is called once the connection has been upgraded. If it
+	// returns an error, the connection is closed.
+	OnConnect() error
+	// OnMessage is called for every message received, with the type of the
+	// message as defined by websocket.TextMessage and websocket.BinaryMessage.
+	// If it returns an error, the connection is closed.
+	OnMessage(messageType int, data []byte) error
+	// OnClose is called when the connection is about to be closed, with the
+	// error that ended it. It is a *websocket.CloseError if the client closed
+	// the connection.
+	OnClose(err error)
+
+	socket() *Controller
+}
+
+var (
+	// DefaultPingInterval is the PingInterval of controllers that do not set
+	// one.
+	DefaultPingInterval = 30 * time.Second
+	// DefaultWriteTimeout is the WriteTimeout of controllers that do not set
+	// one.
+	DefaultWriteTimeout = 10 * time.Second
+)
+
+// Controller is a controller.Base for WebSocket endpoints. Its callbacks do
+// nothing, so controllers only implement the ones they need.
+type Controller struct {
+	controller.Base
+
+	// Upgrader upgrades the request to a WebSocket connection. Init may
+	// configure it, for instance to check the origin of the request. Errors
+	// of the upgrade are reported through the Error method of the controller.
+	Upgrader websocket.Upgrader
+	// PingInterval is how often the connection is pinged. The connection is
+	// closed if nothing, not even a pong, is received from the client for
+	// twice as long. It defaults to DefaultPingInterval, and a negative
+	// interval disables pings.
+	PingInterval time.Duration
+	// WriteTimeout limits the time writing a message may take. It defaults
+	// to DefaultWriteTimeout.
+	WriteTimeout time.Duration
+	// Conn is the connection, once upgraded. Messages should be written with
+	// the methods of Controller, which may be called concurrently, rather
+	// than with Conn directly.
+	Conn *websocket.Conn
+
+	writeMu sync.Mutex
+}
+
+// OnConnect does nothing.
+func (c *Controller) OnConnect() error {
+	return nil
+}
+
+// OnMessage ignores the message.
+func (c *Controller) OnMessage(messageType int, data []byte) error {
+	return nil
+}
+
+// OnClose does nothing.
+func (c *Controller) OnClose(err error) {
+}
+
+// Send writes v to the connection as a JSON text message.
+func (c *Controller) Send(v interface{}) error {
+	return c.write(func() error { return c.Conn.WriteJSON(v) })
+}
+
+// SendText writes s to the connection as a text message.
+func (c *Controller) SendText(s string) error {
+	return c.WriteMessage(websocket.TextMessage, []byte(s))
+}
+
+// WriteMessage writes a message of the given type to the connection.
+func (c *Controller) WriteMessage(messageType int, data []byte) error {
+	return c.write(func() error { return c.Conn.WriteMessage(messageType, data) })
+}
+
+// write calls w with the writes of other goroutines locked out and the
+// write deadline set.
+func (c *Controller) write(w func() error) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if err := c.Conn.SetWriteDeadline(time.Now().Add(c.writeTimeout())); err != nil {
+		return err
+	}
+	return w()
+}
+
+func (c *Controller) writeTimeout() time.Duration {
+	if c.WriteTimeout > 0 {
+		return c.WriteTimeout
+	}
+	return DefaultWriteTimeout
+}
+
+func (c *Controller) pingInterval() time.Duration {
+	if c.PingInterval == 0 {
+		return DefaultPingInterval
+	}
+	return c.PingInterval
+}
+
+// keepAlive pings the connection every interval until done is closed.
+// WriteControl may be called concurrently with the other writes.
+func (c *Controller) keepAlive(interval time.Duration, done <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := c.Conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(c.writeTimeout())); err != nil {
+				return
+			}
+		case <-done:
+			return
+		}
+	}
+}
+
+func (c *Controller) socket() *Controller {
+	return c
+}
+
+// Action returns a handler serving WebSocket connections with controllers of
+// type T, constructed like for controller.ActionOf.
+func Action[T any, PT interface {
+	*T
+	Handler
+}](opts ...controller.Option) http.Handler {
+	return controller.Handler(
+		controller.NewFunc[T, PT](),
+		func(c controller.Controller, r *http.Request) error {
+			return serve(c.(PT))
+		},
+		opts...,
+	)
+}
+
+// serve upgrades the request of h and feeds the messages received to h until
+// the connection is closed.
+func serve(h Handler) error {
+	c := h.socket()
+	var upgradeErr error
+	upgrader := c.Upgrader
+	upgrader.Error = func(w http.ResponseWriter, r *http.Request, status int, reason error) {
+		upgradeErr = &controller.HTTPError{Code: status, Err: reason}
+	}
+	conn, err := upgrader.Upgrade(c.ResponseWriter, c.Request, nil)
+	if err != nil {
+		if upgradeErr != nil {
+			return upgradeErr
+		}
+		return err
+	}
+	c.Conn = conn
+	defer conn.Close()
+
+	// Anything received from the client, pongs included, proves that the
+	// connection is alive.
+	interval := c.pingInterval()
+	alive := func(string) error {
+		if interval < 0 {
+			return nil
+		}
+		return conn.SetReadDeadline(time.Now().Add(2 * interval))
+	}
+	if interval > 0 {
+		alive("")
+		conn.SetPongHandler(alive)
+		done := make(chan struct{})
+		defer close(done)
+		go c.keepAlive(interval, done)
+	}
+
+	if err := h.OnConnect(); err != nil {
+		h.OnClose(err)
+		return nil
+	}
+	for {
+		messageType, data, err := conn.ReadMessage()
+		if err == nil {
+			err = alive("")
+		}
+		if err == nil {
+			err = h.OnMessage(messageType, data)
+		}
+		if err != nil {
+			h.OnClose(err)
+			return nil
+		}
+	}
+}
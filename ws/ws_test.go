@@ -0,0 +1,168 @@
+package ws
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/codegangsta/controller"
+	"github.com/gorilla/websocket"
+)
+
+type EchoController struct {
+	Controller
+}
+
+func (c *EchoController) OnConnect() error {
+	return c.SendText("hello " + c.Request.PathValue("name"))
+}
+
+func (c *EchoController) OnMessage(messageType int, data []byte) error {
+	return c.WriteMessage(messageType, data)
+}
+
+func (c *EchoController) OnClose(err error) {
+	closed <- err
+}
+
+var closed = make(chan error, 1)
+
+func TestAction(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.Handle("GET /ws/{name}", Action[EchoController]())
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	url := "ws" + strings.TrimPrefix(srv.URL, "http") + "/ws/gopher"
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"hello gopher", "ping"} {
+		if want == "ping" {
+			if err := conn.WriteMessage(websocket.TextMessage, []byte(want)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != want {
+			t.Errorf("Expected %q, got %q", want, data)
+		}
+	}
+	conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+	conn.Close()
+	if err := <-closed; !websocket.IsCloseError(err, websocket.CloseNormalClosure) {
+		t.Errorf("Expected a normal closure, got %v", err)
+	}
+
+	resp, err := http.Get(srv.URL + "/ws/gopher")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a plain request, got %d", resp.StatusCode)
+	}
+}
+
+type PingController struct {
+	Controller
+}
+
+func (c *PingController) Init(rw http.ResponseWriter, r *http.Request) error {
+	c.PingInterval = 10 * time.Millisecond
+	return c.Controller.Init(rw, r)
+}
+
+func (c *PingController) OnClose(err error) {
+	closed <- err
+}
+
+func TestKeepAlive(t *testing.T) {
+	srv := httptest.NewServer(Action[PingController]())
+	defer srv.Close()
+
+	url := "ws" + strings.TrimPrefix(srv.URL, "http")
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Pings are answered while the client reads, which keeps the connection
+	// alive.
+	pings := make(chan struct{}, 10)
+	conn.SetPingHandler(func(data string) error {
+		pings <- struct{}{}
+		return conn.WriteControl(websocket.PongMessage, []byte(data), time.Now().Add(time.Second))
+	})
+	go conn.ReadMessage()
+	for i := 0; i < 3; i++ {
+		select {
+		case <-pings:
+		case <-time.After(time.Second):
+			t.Fatal("Expected the connection to be pinged")
+		}
+	}
+	select {
+	case err := <-closed:
+		t.Fatalf("Expected the connection to stay open, got %v", err)
+	default:
+	}
+	conn.Close()
+	<-closed
+}
+
+func TestDeadConnection(t *testing.T) {
+	srv := httptest.NewServer(Action[PingController]())
+	defer srv.Close()
+
+	url := "ws" + strings.TrimPrefix(srv.URL, "http")
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	// The client never reads, so pings go unanswered.
+	select {
+	case err := <-closed:
+		if err == nil {
+			t.Errorf("Expected a timeout error")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected the connection to be closed")
+	}
+}
+
+type ConstructedController struct {
+	Controller
+	greeting string
+}
+
+func (*ConstructedController) New() controller.Controller {
+	return &ConstructedController{greeting: "constructed"}
+}
+
+func (c *ConstructedController) OnConnect() error {
+	return c.SendText(c.greeting)
+}
+
+func TestConstructor(t *testing.T) {
+	srv := httptest.NewServer(Action[ConstructedController]())
+	defer srv.Close()
+
+	url := "ws" + strings.TrimPrefix(srv.URL, "http")
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if _, data, err := conn.ReadMessage(); err != nil || string(data) != "constructed" {
+		t.Errorf("Expected the controller to be built by New, got %q %v", data, err)
+	}
+}